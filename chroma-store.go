@@ -0,0 +1,158 @@
+// chroma-store.go
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/amikos-tech/chroma-go"
+)
+
+// chromaStore is the original backend this project shipped with, now
+// behind the VectorStore interface instead of being hard-wired into
+// VectorDBClient.
+type chromaStore struct {
+	client *chroma.Client
+}
+
+func newChromaStore(host string) (*chromaStore, error) {
+	client, err := chroma.NewClient(host)
+	if err != nil {
+		return nil, err
+	}
+	return &chromaStore{client: client}, nil
+}
+
+func (s *chromaStore) SaveTraces(ctx context.Context, cluster string, batch VectorBatch) error {
+	collection, err := s.client.GetOrCreateCollection(collectionForCluster(cluster), nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = collection.Add(
+		ctx,
+		chroma.NewAddEmbeddings(batch.Embeddings).
+			WithDocuments(batch.Documents).
+			WithMetadatas(batch.Metadatas).
+			WithIDs(batch.IDs),
+	)
+	return err
+}
+
+func (s *chromaStore) SaveLogs(ctx context.Context, cluster string, batch VectorBatch) error {
+	collection, err := s.client.GetOrCreateCollection(collectionForCluster(cluster)+"_logs", nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = collection.Add(
+		ctx,
+		chroma.NewAddEmbeddings(batch.Embeddings).
+			WithDocuments(batch.Documents).
+			WithMetadatas(batch.Metadatas).
+			WithIDs(batch.IDs),
+	)
+	return err
+}
+
+func (s *chromaStore) SearchSimilar(ctx context.Context, queryEmbedding []float32, limit int, filter SearchFilter) ([]SearchResult, error) {
+	collection, err := s.client.GetCollection(collectionForCluster(filter.Cluster))
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := collection.Query(
+		ctx,
+		chroma.NewQueryEmbeddings([][]float32{queryEmbedding}).
+			WithNResults(limit).
+			WithWhere(chromaWhereClause(filter)).
+			WithInclude([]string{"metadatas", "documents", "distances"}),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return convertToSearchResults(results), nil
+}
+
+func (s *chromaStore) ListTracesInWindow(ctx context.Context, cluster string, from, to time.Time) ([]VectorRecord, error) {
+	return s.listInWindow(ctx, collectionForCluster(cluster), from, to)
+}
+
+func (s *chromaStore) ListLogsInWindow(ctx context.Context, cluster string, from, to time.Time) ([]VectorRecord, error) {
+	return s.listInWindow(ctx, collectionForCluster(cluster)+"_logs", from, to)
+}
+
+// listInWindow is a plain metadata "get", not a nearest-neighbour query —
+// no embedding involved, so it's usable for the correlation engine's
+// windowed scans where there's no query vector to rank against.
+func (s *chromaStore) listInWindow(ctx context.Context, collectionName string, from, to time.Time) ([]VectorRecord, error) {
+	collection, err := s.client.GetCollection(collectionName)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := collection.Get(
+		ctx,
+		chroma.NewGetEmbedding().
+			WithWhere(chromaWhereClause(SearchFilter{From: from, To: to})).
+			WithInclude([]string{"metadatas", "documents"}),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return chromaGetResultToRecords(result), nil
+}
+
+func (s *chromaStore) DeleteByCluster(ctx context.Context, cluster string) error {
+	return s.client.DeleteCollection(collectionForCluster(cluster))
+}
+
+func (s *chromaStore) Compact(ctx context.Context) error {
+	// ChromaDB compacts its own segments in the background; nothing for
+	// us to trigger here.
+	return nil
+}
+
+// chromaWhereClause maps SearchFilter onto Chroma's metadata "where"
+// filter syntax, skipping any axis the caller left unset.
+func chromaWhereClause(filter SearchFilter) map[string]interface{} {
+	where := map[string]interface{}{}
+	if filter.ServiceName != "" {
+		where["service_name"] = filter.ServiceName
+	}
+	if filter.SpanKind != "" {
+		where["span_kind"] = filter.SpanKind
+	}
+	if !filter.From.IsZero() {
+		where["timestamp"] = map[string]interface{}{"$gte": filter.From.UnixNano()}
+	}
+	if !filter.To.IsZero() {
+		if existing, ok := where["timestamp"].(map[string]interface{}); ok {
+			existing["$lte"] = filter.To.UnixNano()
+		} else {
+			where["timestamp"] = map[string]interface{}{"$lte": filter.To.UnixNano()}
+		}
+	}
+	return where
+}
+
+// chromaGetResultToRecords unpacks a collection.Get response's parallel
+// Ids/Documents/Metadatas arrays into the backend-agnostic VectorRecord
+// shape the correlation engine consumes.
+func chromaGetResultToRecords(result *chroma.GetResult) []VectorRecord {
+	records := make([]VectorRecord, 0, len(result.Ids))
+	for i, id := range result.Ids {
+		var document string
+		if i < len(result.Documents) {
+			document = result.Documents[i]
+		}
+		var metadata map[string]interface{}
+		if i < len(result.Metadatas) {
+			metadata = result.Metadatas[i]
+		}
+		records = append(records, VectorRecord{ID: id, Document: document, Metadata: metadata})
+	}
+	return records
+}
@@ -0,0 +1,96 @@
+// correlation-datasource.go
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// fetchSpansInWindow and fetchLogsInWindow are the two data sources the
+// correlation engine needs: every span/log in a time window for a
+// cluster. Traces and logs are already persisted as embeddings+metadata
+// in VectorStore (see vector-store.go), so these scan that same store
+// via ListTracesInWindow/ListLogsInWindow rather than standing up a
+// separate raw-event store, and decode each VectorRecord's metadata back
+// into the shape the correlation engine works with.
+func fetchSpansInWindow(ctx context.Context, clusterName string, from, to time.Time) ([]Span, error) {
+	records, err := vectorDBClient.ListTracesInWindow(clusterName, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	spans := make([]Span, 0, len(records))
+	for _, r := range records {
+		spans = append(spans, spanFromMetadata(r.Metadata))
+	}
+	return spans, nil
+}
+
+func fetchLogsInWindow(ctx context.Context, clusterName string, from, to time.Time) ([]LogLine, error) {
+	records, err := vectorDBClient.ListLogsInWindow(clusterName, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	logs := make([]LogLine, 0, len(records))
+	for _, r := range records {
+		logs = append(logs, logLineFromRecord(r))
+	}
+	return logs, nil
+}
+
+// spanFromMetadata reads the same keys weaviate-store.go's
+// vectorRecordFields (and the other backends' metadata maps) carry for a
+// trace span. Fields with no value at the expected type are left at
+// their zero value rather than failing the whole scan.
+func spanFromMetadata(metadata map[string]interface{}) Span {
+	return Span{
+		TraceID:      metadataString(metadata, "trace_id"),
+		SpanID:       metadataString(metadata, "span_id"),
+		ParentSpanID: metadataString(metadata, "parent_span_id"),
+		ServiceName:  metadataString(metadata, "service_name"),
+		PodName:      metadataString(metadata, "pod_name"),
+		StartTime:    metadataTime(metadata, "timestamp"),
+		EndTime:      metadataTime(metadata, "end_time"),
+		IsError:      metadataBool(metadata, "is_error"),
+	}
+}
+
+// logLineFromRecord mirrors spanFromMetadata; a log's message is the
+// VectorRecord's Document (the text that was embedded), not a metadata
+// field.
+func logLineFromRecord(r VectorRecord) LogLine {
+	return LogLine{
+		Timestamp:   metadataTime(r.Metadata, "timestamp"),
+		PodName:     metadataString(r.Metadata, "pod_name"),
+		TraceID:     metadataString(r.Metadata, "trace_id"),
+		SpanID:      metadataString(r.Metadata, "span_id"),
+		ServiceName: metadataString(r.Metadata, "service_name"),
+		Message:     r.Document,
+	}
+}
+
+func metadataString(metadata map[string]interface{}, key string) string {
+	s, _ := metadata[key].(string)
+	return s
+}
+
+func metadataBool(metadata map[string]interface{}, key string) bool {
+	b, _ := metadata[key].(bool)
+	return b
+}
+
+// metadataTime reads a Unix-nanoseconds timestamp, matching how
+// qdrantPayloadFromMetadata/pgvectorObservedAt/chromaWhereClause already
+// store "timestamp"/"end_time" — but coming back from a GraphQL or JSON
+// round-trip it may arrive as a float64 rather than an int64.
+func metadataTime(metadata map[string]interface{}, key string) time.Time {
+	switch ts := metadata[key].(type) {
+	case int64:
+		return time.Unix(0, ts)
+	case float64:
+		return time.Unix(0, int64(ts))
+	default:
+		return time.Time{}
+	}
+}
@@ -0,0 +1,351 @@
+// correlation.go
+package main
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CorrelationRequest describes an incident window and an optional seed
+// to anchor the search — mirrors the params handleIncidentAnalysis
+// already accepts, minus the free-text prompt.
+type CorrelationRequest struct {
+	ClusterName  string
+	From         time.Time
+	To           time.Time
+	SeedService  string
+	SeedTraceID  string
+	SeedErrorMsg string
+	TopK         int
+}
+
+// Span is the subset of a trace span the correlation engine needs. It's
+// deliberately smaller than a full OTLP span — everything else gets
+// dropped once we've built the dependency graph.
+type Span struct {
+	TraceID      string
+	SpanID       string
+	ParentSpanID string
+	ServiceName  string
+	PodName      string
+	StartTime    time.Time
+	EndTime      time.Time
+	IsError      bool
+}
+
+// LogLine is the subset of a log entry needed to join it to a span.
+type LogLine struct {
+	Timestamp   time.Time
+	PodName     string
+	TraceID     string
+	SpanID      string
+	ServiceName string
+	Message     string
+}
+
+// ServiceNode is one vertex of the reconstructed service-dependency
+// graph, scored for how likely it is to be the incident's root cause.
+type ServiceNode struct {
+	ServiceName         string   `json:"service_name"`
+	ErrorRateDelta      float64  `json:"error_rate_delta"`
+	EarliestFailureFrac float64  `json:"earliest_failure_fraction"`
+	Fanout              int      `json:"fanout"`
+	Score               float64  `json:"score"`
+	RepresentativeTraces []string `json:"representative_trace_ids"`
+	NearestLogLines     []string `json:"nearest_log_lines"`
+}
+
+// CorrelationGraph is the JSON-able result handed back to the UI.
+type CorrelationGraph struct {
+	Nodes []ServiceNode       `json:"nodes"`
+	Edges []ServiceDependency `json:"edges"`
+}
+
+// ServiceDependency is a directed edge caller -> callee, weighted by how
+// often the callee's spans were the child of the caller's spans across
+// all traces in the window, failing or not.
+type ServiceDependency struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Count int    `json:"count"`
+}
+
+// CorrelationEngine joins traces, logs and a baseline window into a
+// ranked root-cause graph. It depends only on VectorDBClient's search
+// surface plus two data fetchers the analyzer already has elsewhere
+// (fetchSpansInWindow / fetchLogsInWindow), so it has no ChromaDB- or
+// OTLP-specific code of its own.
+type CorrelationEngine struct {
+	vectorDB *VectorDBClient
+}
+
+func NewCorrelationEngine(vectorDB *VectorDBClient) *CorrelationEngine {
+	return &CorrelationEngine{vectorDB: vectorDB}
+}
+
+// traceTree is a trace's spans keyed by span ID, used to walk
+// parent/child relationships and find each trace's earliest failing
+// span.
+type traceTree struct {
+	traceID string
+	spans   map[string]*Span
+}
+
+// Correlate implements the root-cause ranking described in the request:
+// for each service, score = (error rate delta) * (fraction of failing
+// traces whose earliest failing span is in this service) * (1 / fanout).
+func (e *CorrelationEngine) Correlate(ctx context.Context, req CorrelationRequest) (*CorrelationGraph, error) {
+	if req.TopK <= 0 {
+		req.TopK = 5
+	}
+
+	spans, err := fetchSpansInWindow(ctx, req.ClusterName, req.From, req.To)
+	if err != nil {
+		return nil, err
+	}
+
+	baselineFrom, baselineTo := baselineWindow(req.From, req.To)
+	baselineSpans, err := fetchSpansInWindow(ctx, req.ClusterName, baselineFrom, baselineTo)
+	if err != nil {
+		return nil, err
+	}
+
+	logs, err := fetchLogsInWindow(ctx, req.ClusterName, req.From, req.To)
+	if err != nil {
+		return nil, err
+	}
+
+	trees := buildTraceTrees(spans)
+	edges := buildDependencyGraph(trees)
+	fanout := computeFanout(edges)
+
+	errorRate := computeErrorRateByService(spans)
+	baselineErrorRate := computeErrorRateByService(baselineSpans)
+
+	earliestFailureByService, repTraces := computeEarliestFailures(trees)
+	totalFailingTraces := 0
+	for _, trace := range trees {
+		if traceHasError(trace) {
+			totalFailingTraces++
+		}
+	}
+
+	nodes := make([]ServiceNode, 0, len(errorRate))
+	for service, rate := range errorRate {
+		delta := rate - baselineErrorRate[service]
+		frac := 0.0
+		if totalFailingTraces > 0 {
+			frac = float64(earliestFailureByService[service]) / float64(totalFailingTraces)
+		}
+		fanoutForService := fanout[service]
+		if fanoutForService == 0 {
+			fanoutForService = 1
+		}
+
+		score := delta * frac / float64(fanoutForService)
+
+		nodes = append(nodes, ServiceNode{
+			ServiceName:           service,
+			ErrorRateDelta:        delta,
+			EarliestFailureFrac:   frac,
+			Fanout:                fanoutForService,
+			Score:                 score,
+			RepresentativeTraces:  repTraces[service],
+			NearestLogLines:       nearestLogLines(service, repTraces[service], trees, logs),
+		})
+	}
+
+	for i := range nodes {
+		nodes[i].Score = applySeedBoost(nodes[i], req)
+	}
+
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Score > nodes[j].Score })
+	if len(nodes) > req.TopK {
+		nodes = nodes[:req.TopK]
+	}
+
+	return &CorrelationGraph{Nodes: nodes, Edges: edges}, nil
+}
+
+// applySeedBoost anchors the ranking to the caller's optional hint about
+// where the incident already looks like it started: a suspected service,
+// a specific trace, or an error message they've spotted. None of these
+// change which services are candidates — the unweighted score above
+// already decided that — they only reorder among candidates that match,
+// so a seed pointing at the wrong service can't manufacture a root cause
+// that isn't otherwise supported by the data.
+func applySeedBoost(node ServiceNode, req CorrelationRequest) float64 {
+	const matchBoost = 1.5
+
+	score := node.Score
+	if req.SeedService != "" && node.ServiceName == req.SeedService {
+		score *= matchBoost
+	}
+	if req.SeedTraceID != "" {
+		for _, traceID := range node.RepresentativeTraces {
+			if traceID == req.SeedTraceID {
+				score *= matchBoost
+				break
+			}
+		}
+	}
+	if req.SeedErrorMsg != "" {
+		for _, line := range node.NearestLogLines {
+			if strings.Contains(line, req.SeedErrorMsg) {
+				score *= matchBoost
+				break
+			}
+		}
+	}
+	return score
+}
+
+func baselineWindow(from, to time.Time) (time.Time, time.Time) {
+	width := to.Sub(from)
+	return from.Add(-width), from
+}
+
+func buildTraceTrees(spans []Span) map[string]*traceTree {
+	trees := map[string]*traceTree{}
+	for i := range spans {
+		s := &spans[i]
+		tree, ok := trees[s.TraceID]
+		if !ok {
+			tree = &traceTree{traceID: s.TraceID, spans: map[string]*Span{}}
+			trees[s.TraceID] = tree
+		}
+		tree.spans[s.SpanID] = s
+	}
+	return trees
+}
+
+// buildDependencyGraph counts, for every parent/child span pair, an edge
+// from the parent's service to the child's service.
+func buildDependencyGraph(trees map[string]*traceTree) []ServiceDependency {
+	counts := map[[2]string]int{}
+	for _, tree := range trees {
+		for _, span := range tree.spans {
+			parent, ok := tree.spans[span.ParentSpanID]
+			if !ok || parent.ServiceName == span.ServiceName {
+				continue
+			}
+			counts[[2]string{parent.ServiceName, span.ServiceName}]++
+		}
+	}
+
+	edges := make([]ServiceDependency, 0, len(counts))
+	for pair, count := range counts {
+		edges = append(edges, ServiceDependency{From: pair[0], To: pair[1], Count: count})
+	}
+	return edges
+}
+
+func computeFanout(edges []ServiceDependency) map[string]int {
+	fanout := map[string]int{}
+	for _, e := range edges {
+		fanout[e.From]++
+	}
+	return fanout
+}
+
+func computeErrorRateByService(spans []Span) map[string]float64 {
+	total := map[string]int{}
+	errored := map[string]int{}
+	for _, s := range spans {
+		total[s.ServiceName]++
+		if s.IsError {
+			errored[s.ServiceName]++
+		}
+	}
+
+	rate := make(map[string]float64, len(total))
+	for service, count := range total {
+		rate[service] = float64(errored[service]) / float64(count)
+	}
+	return rate
+}
+
+func traceHasError(tree *traceTree) bool {
+	for _, s := range tree.spans {
+		if s.IsError {
+			return true
+		}
+	}
+	return false
+}
+
+// computeEarliestFailures finds, for every failing trace, the first span
+// (by start time) that is itself an error, and attributes that trace to
+// that span's service.
+func computeEarliestFailures(trees map[string]*traceTree) (counts map[string]int, representative map[string][]string) {
+	counts = map[string]int{}
+	representative = map[string][]string{}
+
+	for traceID, tree := range trees {
+		var earliest *Span
+		for _, s := range tree.spans {
+			if !s.IsError {
+				continue
+			}
+			if earliest == nil || s.StartTime.Before(earliest.StartTime) {
+				earliest = s
+			}
+		}
+		if earliest == nil {
+			continue
+		}
+		counts[earliest.ServiceName]++
+		if len(representative[earliest.ServiceName]) < 5 {
+			representative[earliest.ServiceName] = append(representative[earliest.ServiceName], traceID)
+		}
+	}
+	return counts, representative
+}
+
+// nearestLogLines collects log lines for a service's representative
+// traces. A log line carrying one of those trace IDs is taken as is; its
+// span_id is not compared against any span. A log line with no trace ID
+// falls back to a time+pod-name heuristic: same pod as one of the
+// service's failing spans in those traces, within 2s of that span. Lines
+// come back in the order of logs, not ranked by distance to a span.
+func nearestLogLines(service string, traceIDs []string, trees map[string]*traceTree, logs []LogLine) []string {
+	var lines []string
+	traceSet := make(map[string]bool, len(traceIDs))
+	for _, id := range traceIDs {
+		traceSet[id] = true
+	}
+
+	var earliestSpans []*Span
+	for _, traceID := range traceIDs {
+		tree := trees[traceID]
+		for _, s := range tree.spans {
+			if s.ServiceName == service && s.IsError {
+				earliestSpans = append(earliestSpans, s)
+			}
+		}
+	}
+
+	for _, log := range logs {
+		if traceSet[log.TraceID] {
+			lines = append(lines, log.Message)
+			continue
+		}
+		if log.TraceID != "" {
+			continue
+		}
+		for _, span := range earliestSpans {
+			if log.PodName != "" && log.PodName == span.PodName &&
+				log.Timestamp.After(span.StartTime.Add(-2*time.Second)) &&
+				log.Timestamp.Before(span.EndTime.Add(2*time.Second)) {
+				lines = append(lines, log.Message)
+				break
+			}
+		}
+		if len(lines) >= 10 {
+			break
+		}
+	}
+	return lines
+}
@@ -0,0 +1,140 @@
+// embedder-onnx.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	ort "github.com/yalue/onnxruntime_go"
+)
+
+// tokenizeForMiniLM is a minimal whitespace tokenizer with a hashed
+// vocabulary fallback. It's good enough to exercise the ONNX path
+// end-to-end; swapping in the model's real WordPiece vocab (shipped
+// alongside the .onnx file) would only change how token IDs are looked
+// up, not the rest of this file.
+func tokenizeForMiniLM(text string) (inputIDs, attentionMask []int64, err error) {
+	const maxTokens = 128
+	const clsToken, sepToken = 101, 102
+
+	words := strings.Fields(text)
+	if len(words) > maxTokens-2 {
+		words = words[:maxTokens-2]
+	}
+
+	inputIDs = append(inputIDs, clsToken)
+	for _, w := range words {
+		inputIDs = append(inputIDs, hashToVocabID(w))
+	}
+	inputIDs = append(inputIDs, sepToken)
+
+	attentionMask = make([]int64, len(inputIDs))
+	for i := range attentionMask {
+		attentionMask[i] = 1
+	}
+	return inputIDs, attentionMask, nil
+}
+
+func hashToVocabID(word string) int64 {
+	const vocabSize = 30522 // MiniLM's BERT-base-uncased vocab size
+	var h int64 = 2166136261
+	for _, b := range []byte(word) {
+		h = (h ^ int64(b)) * 16777619
+	}
+	if h < 0 {
+		h = -h
+	}
+	return 999 + h%(vocabSize-999)
+}
+
+// onnxEmbedder runs a local sentence-transformer model (all-MiniLM-L6-v2
+// by default) through onnxruntime, so embedding doesn't require an
+// outbound call to a remote API on the ingestion hot path.
+type onnxEmbedder struct {
+	modelPath string
+
+	initOnce sync.Once
+	initErr  error
+	session  *ort.AdvancedSession
+}
+
+func newONNXEmbedder(modelPath string) *onnxEmbedder {
+	if modelPath == "" {
+		modelPath = "models/all-MiniLM-L6-v2.onnx"
+	}
+	return &onnxEmbedder{modelPath: modelPath}
+}
+
+func (e *onnxEmbedder) ensureSession() error {
+	e.initOnce.Do(func() {
+		if err := ort.InitializeEnvironment(); err != nil {
+			e.initErr = fmt.Errorf("initializing onnxruntime: %w", err)
+			return
+		}
+
+		session, err := ort.NewAdvancedSession(e.modelPath,
+			[]string{"input_ids", "attention_mask"},
+			[]string{"sentence_embedding"},
+			nil, nil)
+		if err != nil {
+			e.initErr = fmt.Errorf("loading onnx model %s: %w", e.modelPath, err)
+			return
+		}
+		e.session = session
+	})
+	return e.initErr
+}
+
+// Embed tokenizes each text with tokenizeForMiniLM (above) and runs it
+// through the model, one input per inference call.
+func (e *onnxEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if err := e.ensureSession(); err != nil {
+		return nil, err
+	}
+
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		inputIDs, attentionMask, err := tokenizeForMiniLM(text)
+		if err != nil {
+			return nil, fmt.Errorf("tokenizing input %d: %w", i, err)
+		}
+
+		vector, err := e.runInference(inputIDs, attentionMask)
+		if err != nil {
+			return nil, fmt.Errorf("running inference on input %d: %w", i, err)
+		}
+		vectors[i] = vector
+	}
+	return vectors, nil
+}
+
+func (e *onnxEmbedder) runInference(inputIDs, attentionMask []int64) ([]float32, error) {
+	inputShape := ort.NewShape(1, int64(len(inputIDs)))
+
+	idsTensor, err := ort.NewTensor(inputShape, inputIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer idsTensor.Destroy()
+
+	maskTensor, err := ort.NewTensor(inputShape, attentionMask)
+	if err != nil {
+		return nil, err
+	}
+	defer maskTensor.Destroy()
+
+	outputShape := ort.NewShape(1, int64(embeddingDimensions))
+	outputTensor, err := ort.NewEmptyTensor[float32](outputShape)
+	if err != nil {
+		return nil, err
+	}
+	defer outputTensor.Destroy()
+
+	if err := e.session.Run([]ort.Value{idsTensor, maskTensor}, []ort.Value{outputTensor}); err != nil {
+		return nil, err
+	}
+
+	return append([]float32{}, outputTensor.GetData()...), nil
+}
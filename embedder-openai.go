@@ -0,0 +1,79 @@
+// embedder-openai.go
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// openAIEmbedder calls an OpenAI-compatible embeddings endpoint. Kept as
+// an alternative to the local ONNX runner for deployments that would
+// rather not host a model themselves.
+type openAIEmbedder struct {
+	apiKey  string
+	baseURL string
+	model   string
+	client  *http.Client
+}
+
+func newOpenAIEmbedder(apiKey string) *openAIEmbedder {
+	return &openAIEmbedder{
+		apiKey:  apiKey,
+		baseURL: "https://api.openai.com/v1",
+		model:   "text-embedding-3-small",
+		client:  http.DefaultClient,
+	}
+}
+
+type openAIEmbeddingRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type openAIEmbeddingResponse struct {
+	Data []struct {
+		Embedding []float32 `json:"embedding"`
+		Index     int       `json:"index"`
+	} `json:"data"`
+}
+
+// Embed sends the whole slice as a single request — the OpenAI
+// embeddings endpoint already accepts a batch of inputs natively, so the
+// batchingEmbedder wrapper and this call compose cleanly.
+func (e *openAIEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	body, err := json.Marshal(openAIEmbeddingRequest{Model: e.model, Input: texts})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.baseURL+"/embeddings", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+e.apiKey)
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("calling embeddings endpoint: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("embeddings endpoint returned %d", resp.StatusCode)
+	}
+
+	var parsed openAIEmbeddingResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return nil, fmt.Errorf("decoding embeddings response: %w", err)
+	}
+
+	vectors := make([][]float32, len(texts))
+	for _, d := range parsed.Data {
+		vectors[d.Index] = d.Embedding
+	}
+	return vectors, nil
+}
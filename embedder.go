@@ -0,0 +1,220 @@
+// embedder.go
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"sync"
+	"time"
+)
+
+// Embedder turns text into vectors. It's injected into VectorDBClient so
+// tests can stub it out instead of hitting a real model or API.
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// newConfiguredEmbedder picks a backend from EMBEDDING_BACKEND (default
+// "onnx", a local all-MiniLM-L6-v2 model, so a fresh checkout doesn't
+// require an API key to run). Whatever backend is chosen is wrapped in
+// batching + dedup, since those pay off regardless of where the vectors
+// ultimately come from.
+func newConfiguredEmbedder() Embedder {
+	var inner Embedder
+	switch os.Getenv("EMBEDDING_BACKEND") {
+	case "openai":
+		inner = newOpenAIEmbedder(os.Getenv("OPENAI_API_KEY"))
+	default:
+		inner = newONNXEmbedder(os.Getenv("ONNX_MODEL_PATH"))
+	}
+
+	return newBatchingEmbedder(inner, BatchingConfig{
+		MaxBatchSize: 64,
+		MaxWait:      20 * time.Millisecond,
+		CacheTTL:     10 * time.Minute,
+	})
+}
+
+// BatchingConfig controls how aggressively batchingEmbedder coalesces
+// concurrent Embed calls.
+type BatchingConfig struct {
+	MaxBatchSize int
+	MaxWait      time.Duration
+	CacheTTL     time.Duration
+}
+
+// batchingEmbedder batches concurrent Embed requests up to MaxBatchSize
+// items or MaxWait, whichever comes first, and deduplicates identical
+// inputs within a batch (and across batches, via a short-lived
+// content-hash cache) before ever calling the inner embedder. This is
+// what makes high-cardinality log ingestion affordable: most log lines
+// repeat near-verbatim within a short window.
+type batchingEmbedder struct {
+	inner Embedder
+	cfg   BatchingConfig
+
+	mu    sync.Mutex
+	cache map[string]cachedEmbedding
+
+	pending    []pendingRequest
+	flushTimer *time.Timer
+}
+
+type cachedEmbedding struct {
+	vector    []float32
+	expiresAt time.Time
+}
+
+type pendingRequest struct {
+	text   string
+	result chan embedResult
+}
+
+type embedResult struct {
+	vector []float32
+	err    error
+}
+
+func newBatchingEmbedder(inner Embedder, cfg BatchingConfig) *batchingEmbedder {
+	return &batchingEmbedder{inner: inner, cfg: cfg, cache: map[string]cachedEmbedding{}}
+}
+
+// Embed batches and deduplicates texts before delegating to the inner
+// embedder. Callers that already have a batch (e.g. processing a whole
+// OTLP payload at once) should call this with all of their texts in one
+// slice rather than looping — that skips the batching queue entirely and
+// pays only for cache lookups plus one inner call for the misses.
+func (b *batchingEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) > 1 {
+		return b.embedMany(ctx, texts)
+	}
+	if len(texts) == 0 {
+		return nil, nil
+	}
+	return b.embedOne(ctx, texts[0])
+}
+
+// embedMany is the fast path for callers that already have a batch: look
+// up the cache, embed only the misses in one inner call, and populate
+// the cache for next time.
+func (b *batchingEmbedder) embedMany(ctx context.Context, texts []string) ([][]float32, error) {
+	results := make([][]float32, len(texts))
+	misses := map[string][]int{}
+
+	b.mu.Lock()
+	now := time.Now()
+	for i, text := range texts {
+		key := contentHash(text)
+		if cached, ok := b.cache[key]; ok && now.Before(cached.expiresAt) {
+			results[i] = cached.vector
+			continue
+		}
+		misses[key] = append(misses[key], i)
+	}
+	b.mu.Unlock()
+
+	if len(misses) == 0 {
+		return results, nil
+	}
+
+	uniqueTexts := make([]string, 0, len(misses))
+	keys := make([]string, 0, len(misses))
+	for key, idxs := range misses {
+		uniqueTexts = append(uniqueTexts, texts[idxs[0]])
+		keys = append(keys, key)
+	}
+
+	vectors, err := b.inner.Embed(ctx, uniqueTexts)
+	if err != nil {
+		return nil, err
+	}
+
+	b.mu.Lock()
+	expiresAt := time.Now().Add(b.cfg.CacheTTL)
+	for i, key := range keys {
+		b.cache[key] = cachedEmbedding{vector: vectors[i], expiresAt: expiresAt}
+		for _, idx := range misses[key] {
+			results[idx] = vectors[i]
+		}
+	}
+	b.mu.Unlock()
+
+	return results, nil
+}
+
+// embedOne is the path used by single-string callers like
+// SearchSimilarIssues: it joins the in-flight batch window instead of
+// firing a request immediately, so a burst of concurrent searches for
+// similar queries collapses into one inner Embed call.
+func (b *batchingEmbedder) embedOne(ctx context.Context, text string) ([]float32, error) {
+	if v, ok := b.cachedVector(text); ok {
+		return v, nil
+	}
+
+	req := pendingRequest{text: text, result: make(chan embedResult, 1)}
+
+	b.mu.Lock()
+	b.pending = append(b.pending, req)
+	if len(b.pending) >= b.cfg.MaxBatchSize {
+		batch := b.pending
+		b.pending = nil
+		if b.flushTimer != nil {
+			b.flushTimer.Stop()
+			b.flushTimer = nil
+		}
+		go b.flush(ctx, batch)
+	} else if b.flushTimer == nil {
+		b.flushTimer = time.AfterFunc(b.cfg.MaxWait, func() {
+			b.mu.Lock()
+			batch := b.pending
+			b.pending = nil
+			b.flushTimer = nil
+			b.mu.Unlock()
+			if len(batch) > 0 {
+				b.flush(ctx, batch)
+			}
+		})
+	}
+	b.mu.Unlock()
+
+	select {
+	case res := <-req.result:
+		return res.vector, res.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (b *batchingEmbedder) flush(ctx context.Context, batch []pendingRequest) {
+	texts := make([]string, len(batch))
+	for i, req := range batch {
+		texts[i] = req.text
+	}
+
+	vectors, err := b.embedMany(ctx, texts)
+	for i, req := range batch {
+		if err != nil {
+			req.result <- embedResult{err: err}
+			continue
+		}
+		req.result <- embedResult{vector: vectors[i]}
+	}
+}
+
+func (b *batchingEmbedder) cachedVector(text string) ([]float32, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	cached, ok := b.cache[contentHash(text)]
+	if !ok || time.Now().After(cached.expiresAt) {
+		return nil, false
+	}
+	return cached.vector, true
+}
+
+func contentHash(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,49 @@
+// handler-correlate.go
+package main
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// correlateRequestBody is the JSON body for POST /api/analyze/incident/correlate.
+type correlateRequestBody struct {
+	ClusterName  string    `json:"cluster_name" binding:"required"`
+	From         time.Time `json:"from" binding:"required"`
+	To           time.Time `json:"to" binding:"required"`
+	SeedService  string    `json:"seed_service"`
+	SeedTraceID  string    `json:"seed_trace_id"`
+	SeedErrorMsg string    `json:"seed_error_message"`
+	TopK         int       `json:"top_k"`
+}
+
+// correlationEngine is wired up once at startup alongside vectorDBClient.
+var correlationEngine *CorrelationEngine
+
+// handleIncidentCorrelate reconstructs the service-dependency graph for
+// an incident window and ranks candidate root causes, per
+// CorrelationEngine.Correlate.
+func handleIncidentCorrelate(c *gin.Context) {
+	var body correlateRequestBody
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	graph, err := correlationEngine.Correlate(c.Request.Context(), CorrelationRequest{
+		ClusterName:  body.ClusterName,
+		From:         body.From,
+		To:           body.To,
+		SeedService:  body.SeedService,
+		SeedTraceID:  body.SeedTraceID,
+		SeedErrorMsg: body.SeedErrorMsg,
+		TopK:         body.TopK,
+	})
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, graph)
+}
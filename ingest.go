@@ -0,0 +1,98 @@
+// ingest.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/lovemetrue/ai-logs-traces-analyzer/internal/pipeline"
+)
+
+// ingestPipeline fronts every OTLP handler: it durably buffers accepted
+// payloads and replays unacknowledged ones after a restart, so a burst
+// from a cluster no longer OOMs the process or silently drops data on a
+// restart (the old "go processIncomingX(...)" pattern did both). The
+// pipeline retries a job with backoff only when ingestSink returns an
+// error, which it does only for a payload that cannot be decoded; a
+// failed save inside processIncoming*Data is not seen by the pipeline
+// and is not retried.
+var ingestPipeline *pipeline.Pipeline
+
+func initIngestPipeline() {
+	walDir := os.Getenv("PIPELINE_WAL_DIR")
+	if walDir == "" {
+		walDir = "./data/pipeline-wal"
+	}
+
+	p, err := pipeline.New(pipeline.DefaultConfig(), walDir, ingestSink, pipeline.Hooks{
+		OnDropped: func(signal string) {
+			ingestionDroppedTotal.WithLabelValues(signal).Inc()
+		},
+		OnDepth: func(signal string, depth int) {
+			ingestionQueueDepth.WithLabelValues(signal).Set(float64(depth))
+		},
+	})
+	if err != nil {
+		log.Fatalf("failed to start ingestion pipeline: %v", err)
+	}
+	ingestPipeline = p
+}
+
+// ingestSink is the pipeline.Sink: it turns a durable WAL record back into
+// pdata and hands it to the same processIncoming*Data path the live HTTP
+// and gRPC receivers use.
+func ingestSink(ctx context.Context, job pipeline.Job) error {
+	switch job.Signal {
+	case "traces":
+		traces, err := (&ptrace.ProtoUnmarshaler{}).UnmarshalTraces(job.Payload)
+		if err != nil {
+			return fmt.Errorf("unmarshaling buffered traces: %w", err)
+		}
+		processIncomingTraceData(job.ClusterName, traces)
+	case "logs":
+		logs, err := (&plog.ProtoUnmarshaler{}).UnmarshalLogs(job.Payload)
+		if err != nil {
+			return fmt.Errorf("unmarshaling buffered logs: %w", err)
+		}
+		processIncomingLogData(job.ClusterName, logs)
+	case "metrics":
+		metrics, err := (&pmetric.ProtoUnmarshaler{}).UnmarshalMetrics(job.Payload)
+		if err != nil {
+			return fmt.Errorf("unmarshaling buffered metrics: %w", err)
+		}
+		processIncomingMetricData(job.ClusterName, metrics)
+	default:
+		return fmt.Errorf("unknown signal type %q", job.Signal)
+	}
+	return nil
+}
+
+func submitTraces(clusterName string, traces ptrace.Traces) error {
+	payload, err := (&ptrace.ProtoMarshaler{}).MarshalTraces(traces)
+	if err != nil {
+		return err
+	}
+	return ingestPipeline.Submit(pipeline.Job{Signal: "traces", ClusterName: clusterName, Payload: payload})
+}
+
+func submitLogs(clusterName string, logs plog.Logs) error {
+	payload, err := (&plog.ProtoMarshaler{}).MarshalLogs(logs)
+	if err != nil {
+		return err
+	}
+	return ingestPipeline.Submit(pipeline.Job{Signal: "logs", ClusterName: clusterName, Payload: payload})
+}
+
+func submitMetrics(clusterName string, metrics pmetric.Metrics) error {
+	payload, err := (&pmetric.ProtoMarshaler{}).MarshalMetrics(metrics)
+	if err != nil {
+		return err
+	}
+	return ingestPipeline.Submit(pipeline.Job{Signal: "metrics", ClusterName: clusterName, Payload: payload})
+}
@@ -0,0 +1,219 @@
+// Package pipeline implements a bounded, crash-durable ingestion path for
+// OTLP payloads. It replaces the old "go processIncomingX(...)" pattern,
+// which had no bound, no retry, and dropped everything on a restart.
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Sink is whatever ultimately persists a job — today that is
+// VectorDBClient.SaveTraces/SaveLogs, but the pipeline doesn't need to
+// know that.
+type Sink func(ctx context.Context, job Job) error
+
+// Job is a single unit of ingestion work: one OTLP payload from one
+// cluster, already serialized so it can be written to the WAL as-is.
+type Job struct {
+	Signal      string // "traces", "logs" or "metrics"
+	ClusterName string
+	Payload     []byte
+	EnqueuedAt  time.Time
+	attempt     int
+	walOffset   int64
+}
+
+// Config controls queue sizing and retry behaviour. Zero-value Config is
+// not usable; use DefaultConfig() as a base.
+type Config struct {
+	QueueSize     int
+	Workers       int
+	MaxAttempts   int
+	BaseBackoff   time.Duration
+	MaxBackoff    time.Duration
+	PerClusterQPS float64
+}
+
+func DefaultConfig() Config {
+	return Config{
+		QueueSize:     4096,
+		Workers:       8,
+		MaxAttempts:   5,
+		BaseBackoff:   200 * time.Millisecond,
+		MaxBackoff:    30 * time.Second,
+		PerClusterQPS: 200,
+	}
+}
+
+// Pipeline is a bounded worker pool with a durable write-ahead buffer in
+// front of it. Submit never blocks indefinitely: if the queue is full the
+// caller gets ErrQueueFull back and should return 429 upstream.
+type Pipeline struct {
+	cfg     Config
+	sink    Sink
+	wal     *WAL
+	queue   chan Job
+	slots   chan struct{}
+	limiter *clusterLimiter
+
+	dropped func(signal string)
+	depth   func(signal string, n int)
+}
+
+// ErrQueueFull is returned by Submit when the bounded queue has no room
+// and the caller must apply backpressure instead of accepting the job.
+var ErrQueueFull = fmt.Errorf("pipeline: queue is full")
+
+// ErrRateLimited is returned by Submit when the cluster has exceeded its
+// configured PerClusterQPS. Like ErrQueueFull, this is backpressure, not a
+// permanent failure, so callers should map it to the same 429/ResourceExhausted
+// treatment rather than logging it as an ingestion error.
+var ErrRateLimited = fmt.Errorf("pipeline: rate limit exceeded")
+
+// Hooks lets the caller plug in its own metrics without this package
+// depending on a specific metrics library.
+type Hooks struct {
+	OnDropped func(signal string)
+	OnDepth   func(signal string, depth int)
+}
+
+// New starts a pipeline backed by a WAL rooted at walDir. Call Close on
+// shutdown to close the queue and the WAL.
+func New(cfg Config, walDir string, sink Sink, hooks Hooks) (*Pipeline, error) {
+	wal, err := OpenWAL(walDir)
+	if err != nil {
+		return nil, fmt.Errorf("opening WAL: %w", err)
+	}
+
+	slots := make(chan struct{}, cfg.QueueSize)
+	for i := 0; i < cfg.QueueSize; i++ {
+		slots <- struct{}{}
+	}
+
+	p := &Pipeline{
+		cfg:     cfg,
+		sink:    sink,
+		wal:     wal,
+		queue:   make(chan Job, cfg.QueueSize),
+		slots:   slots,
+		limiter: newClusterLimiter(cfg.PerClusterQPS),
+		dropped: hooks.OnDropped,
+		depth:   hooks.OnDepth,
+	}
+
+	for i := 0; i < cfg.Workers; i++ {
+		go p.worker()
+	}
+
+	// Replay anything left over from a previous process that crashed or
+	// was killed before its jobs were acknowledged.
+	go p.replayWAL()
+
+	return p, nil
+}
+
+// Submit enqueues a job for async processing. Acceptance is decided by
+// reserving a slot from p.slots (one per queue capacity) before touching
+// the WAL at all, so a job rejected by the rate limiter or a full queue is
+// never durably stored — it would otherwise be replayed on the next
+// restart and ingested twice despite having been reported to the caller
+// as rejected. Once a slot is reserved, the send to p.queue below is
+// guaranteed not to block, so the WAL write can safely happen first and
+// populate job.walOffset before the job is handed to a worker.
+func (p *Pipeline) Submit(job Job) error {
+	if !p.limiter.Allow(job.ClusterName) {
+		return ErrRateLimited
+	}
+
+	select {
+	case <-p.slots:
+	default:
+		if p.dropped != nil {
+			p.dropped(job.Signal)
+		}
+		return ErrQueueFull
+	}
+
+	offset, err := p.wal.Append(job)
+	if err != nil {
+		p.slots <- struct{}{}
+		return fmt.Errorf("writing to WAL: %w", err)
+	}
+	job.walOffset = offset
+
+	p.queue <- job
+	p.reportDepth(job.Signal)
+	return nil
+}
+
+func (p *Pipeline) reportDepth(signal string) {
+	if p.depth != nil {
+		p.depth(signal, len(p.queue))
+	}
+}
+
+func (p *Pipeline) worker() {
+	for job := range p.queue {
+		p.slots <- struct{}{}
+		p.process(job)
+	}
+}
+
+func (p *Pipeline) process(job Job) {
+	ctx := context.Background()
+
+	var err error
+	for job.attempt = 1; job.attempt <= p.cfg.MaxAttempts; job.attempt++ {
+		err = p.sink(ctx, job)
+		if err == nil {
+			p.wal.Ack(job.walOffset)
+			return
+		}
+
+		backoff := p.cfg.BaseBackoff * time.Duration(1<<uint(job.attempt-1))
+		if backoff > p.cfg.MaxBackoff {
+			backoff = p.cfg.MaxBackoff
+		}
+		time.Sleep(backoff)
+	}
+
+	log.Printf("pipeline: giving up on %s job from %s after %d attempts: %v",
+		job.Signal, job.ClusterName, p.cfg.MaxAttempts, err)
+	// Mark the WAL entry dead rather than acked: replay on restart skips
+	// it, and the record stays in the segment file, flagged "dead" in the
+	// ack index, for an operator to inspect.
+	p.wal.DeadLetter(job.walOffset)
+}
+
+// replayWAL re-submits any job that was appended but never acknowledged
+// by a previous process, so an OOM or SIGKILL doesn't silently drop data.
+func (p *Pipeline) replayWAL() {
+	jobs, err := p.wal.Unacked()
+	if err != nil {
+		log.Printf("pipeline: failed to read WAL for replay: %v", err)
+		return
+	}
+
+	for _, job := range jobs {
+		select {
+		case <-p.slots:
+		default:
+			// Queue is already under pressure right after boot; leave it
+			// in the WAL, it will be retried on the next replay.
+			return
+		}
+		p.queue <- job
+		p.reportDepth(job.Signal)
+	}
+}
+
+// Close closes the job queue and the WAL and returns without waiting for
+// the workers. Jobs still queued or in flight keep running, but their
+// acks can no longer be written, so they are replayed on the next start.
+func (p *Pipeline) Close() error {
+	close(p.queue)
+	return p.wal.Close()
+}
@@ -0,0 +1,37 @@
+package pipeline
+
+import (
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+// clusterLimiter hands out an independent token bucket per cluster name,
+// so one noisy tenant hammering /otlp/v1/traces can't starve the others
+// out of the shared queue.
+type clusterLimiter struct {
+	qps float64
+
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+}
+
+func newClusterLimiter(qps float64) *clusterLimiter {
+	return &clusterLimiter{qps: qps, limiters: map[string]*rate.Limiter{}}
+}
+
+func (c *clusterLimiter) Allow(cluster string) bool {
+	return c.limiterFor(cluster).Allow()
+}
+
+func (c *clusterLimiter) limiterFor(cluster string) *rate.Limiter {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	l, ok := c.limiters[cluster]
+	if !ok {
+		l = rate.NewLimiter(rate.Limit(c.qps), int(c.qps))
+		c.limiters[cluster] = l
+	}
+	return l
+}
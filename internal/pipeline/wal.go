@@ -0,0 +1,197 @@
+package pipeline
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// WAL is a minimal append-only file segment queue: every Submit appends a
+// length-prefixed JSON record, and acknowledged offsets are tracked in a
+// small sidecar index so a restart can tell which records still need to
+// be replayed. This is intentionally simpler than embedding BadgerDB —
+// one append-only file and one offset index is enough durability for a
+// queue that's meant to bridge seconds-to-minutes of downtime, not serve
+// as a general-purpose store.
+type WAL struct {
+	mu       sync.Mutex
+	dataFile *os.File
+	ackFile  *os.File
+	acked    map[int64]bool
+	dead     map[int64]bool
+	// size is the next Append's offset, tracked explicitly rather than
+	// derived from Seek(0, SEEK_CUR): dataFile is opened O_APPEND, so the
+	// file position only advances on a write, not on open — right after a
+	// restart, before any Append, SEEK_CUR would read back 0 even though
+	// the next write lands at EOF.
+	size int64
+}
+
+type walRecord struct {
+	Signal      string `json:"signal"`
+	ClusterName string `json:"cluster_name"`
+	Payload     []byte `json:"payload"`
+}
+
+func OpenWAL(dir string) (*WAL, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	dataFile, err := os.OpenFile(filepath.Join(dir, "segment.log"), os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	ackFile, err := os.OpenFile(filepath.Join(dir, "segment.ack"), os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		dataFile.Close()
+		return nil, err
+	}
+
+	info, err := dataFile.Stat()
+	if err != nil {
+		dataFile.Close()
+		ackFile.Close()
+		return nil, err
+	}
+
+	w := &WAL{dataFile: dataFile, ackFile: ackFile, acked: map[int64]bool{}, dead: map[int64]bool{}, size: info.Size()}
+	if err := w.loadAcks(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *WAL) loadAcks() error {
+	scanner := bufio.NewScanner(w.ackFile)
+	for scanner.Scan() {
+		var offset int64
+		var state string
+		if _, err := fmt.Sscanf(scanner.Text(), "%d %s", &offset, &state); err != nil {
+			continue
+		}
+		if state == "dead" {
+			w.dead[offset] = true
+		} else {
+			w.acked[offset] = true
+		}
+	}
+	return scanner.Err()
+}
+
+// Append writes a job to the segment file and returns the byte offset it
+// was written at, which doubles as its identity for Ack/DeadLetter.
+func (w *WAL) Append(job Job) (int64, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	offset := w.size
+
+	rec := walRecord{Signal: job.Signal, ClusterName: job.ClusterName, Payload: job.Payload}
+	body, err := json.Marshal(rec)
+	if err != nil {
+		return 0, err
+	}
+
+	var lenPrefix [4]byte
+	binary.BigEndian.PutUint32(lenPrefix[:], uint32(len(body)))
+
+	if _, err := w.dataFile.Write(lenPrefix[:]); err != nil {
+		return 0, err
+	}
+	if _, err := w.dataFile.Write(body); err != nil {
+		return 0, err
+	}
+
+	w.size += int64(4 + len(body))
+	return offset, nil
+}
+
+func (w *WAL) Ack(offset int64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.acked[offset] = true
+	fmt.Fprintf(w.ackFile, "%d ack\n", offset)
+}
+
+// DeadLetter marks a job as permanently failed after exhausting retries,
+// so replay on restart doesn't keep retrying it forever.
+func (w *WAL) DeadLetter(offset int64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.dead[offset] = true
+	fmt.Fprintf(w.ackFile, "%d dead\n", offset)
+}
+
+// Unacked re-reads the segment file and returns every job whose offset
+// hasn't been acked or dead-lettered yet — the set a fresh process needs
+// to replay after a crash.
+func (w *WAL) Unacked() ([]Job, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, err := w.dataFile.Seek(0, os.SEEK_SET); err != nil {
+		return nil, err
+	}
+	reader := bufio.NewReader(w.dataFile)
+
+	var jobs []Job
+	var offset int64
+	for {
+		var lenPrefix [4]byte
+		if _, err := readFull(reader, lenPrefix[:]); err != nil {
+			break
+		}
+		size := binary.BigEndian.Uint32(lenPrefix[:])
+
+		body := make([]byte, size)
+		if _, err := readFull(reader, body); err != nil {
+			break
+		}
+
+		if !w.acked[offset] && !w.dead[offset] {
+			var rec walRecord
+			if err := json.Unmarshal(body, &rec); err == nil {
+				jobs = append(jobs, Job{
+					Signal:      rec.Signal,
+					ClusterName: rec.ClusterName,
+					Payload:     rec.Payload,
+					walOffset:   offset,
+				})
+			}
+		}
+
+		offset += int64(4 + size)
+	}
+
+	if _, err := w.dataFile.Seek(0, os.SEEK_END); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+func readFull(r *bufio.Reader, buf []byte) (int, error) {
+	n := 0
+	for n < len(buf) {
+		m, err := r.Read(buf[n:])
+		n += m
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+func (w *WAL) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.dataFile.Close(); err != nil {
+		return err
+	}
+	return w.ackFile.Close()
+}
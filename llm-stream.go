@@ -0,0 +1,115 @@
+// llm-stream.go
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Citation is a single source reference attached to an LLM answer, sent
+// to the client as its own SSE event so the UI can render it inline
+// rather than waiting for the full answer to parse it out of the text.
+type Citation struct {
+	Document string  `json:"document"`
+	Score    float64 `json:"score"`
+}
+
+// streamLLMCompletion calls a streaming chat-completions endpoint
+// (OpenAI-compatible SSE, the same protocol vLLM/Ollama/most hosted
+// providers speak) and returns a channel of tokens as they arrive. The
+// channel is closed when the stream ends or ctx is cancelled.
+func streamLLMCompletion(ctx context.Context, prompt string) (<-chan string, []Citation, error) {
+	baseURL := os.Getenv("LLM_API_BASE")
+	if baseURL == "" {
+		baseURL = "https://api.openai.com/v1"
+	}
+	model := os.Getenv("LLM_MODEL")
+	if model == "" {
+		model = "gpt-4o-mini"
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"model":  model,
+		"stream": true,
+		"messages": []map[string]string{
+			{"role": "user", "content": prompt},
+		},
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+os.Getenv("LLM_API_KEY"))
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("calling LLM endpoint: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, nil, fmt.Errorf("LLM endpoint returned %d", resp.StatusCode)
+	}
+
+	tokens := make(chan string)
+	go func() {
+		defer close(tokens)
+		defer resp.Body.Close()
+
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			select {
+			case <-ctx.Done():
+				return
+			default:
+			}
+
+			line := scanner.Text()
+			if !strings.HasPrefix(line, "data: ") {
+				continue
+			}
+			payload := strings.TrimPrefix(line, "data: ")
+			if payload == "[DONE]" {
+				return
+			}
+
+			token, ok := parseChatCompletionChunk(payload)
+			if ok {
+				tokens <- token
+			}
+		}
+	}()
+
+	// Citations come from the retrieval step, not the model, so they're
+	// attached by the caller once retrieval has already run.
+	return tokens, nil, nil
+}
+
+type chatCompletionChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+}
+
+func parseChatCompletionChunk(payload string) (string, bool) {
+	var chunk chatCompletionChunk
+	if err := json.Unmarshal([]byte(payload), &chunk); err != nil {
+		return "", false
+	}
+	if len(chunk.Choices) == 0 || chunk.Choices[0].Delta.Content == "" {
+		return "", false
+	}
+	return chunk.Choices[0].Delta.Content, true
+}
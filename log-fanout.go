@@ -0,0 +1,74 @@
+// log-fanout.go
+package main
+
+import "sync"
+
+// logLineEvent is one line pushed to /api/logs/stream subscribers.
+type logLineEvent struct {
+	ClusterName string `json:"cluster_name"`
+	ServiceName string `json:"service_name"`
+	Message     string `json:"message"`
+}
+
+// logSubscription is a live tail session: Lines is closed by Unsubscribe,
+// at which point the handler's range loop exits.
+type logSubscription struct {
+	cluster string
+	service string
+	Lines   chan logLineEvent
+}
+
+// logFan is a simple pub/sub fan-out in front of the ingestion pipeline:
+// every log line accepted by processIncomingLogData is also pushed here,
+// so /api/logs/stream doesn't have to poll a store to show operators a
+// live tail.
+type logFan struct {
+	mu   sync.Mutex
+	subs map[*logSubscription]struct{}
+}
+
+var logFanout = &logFan{subs: map[*logSubscription]struct{}{}}
+
+func (f *logFan) Subscribe(cluster, service string) *logSubscription {
+	sub := &logSubscription{cluster: cluster, service: service, Lines: make(chan logLineEvent, 64)}
+
+	f.mu.Lock()
+	f.subs[sub] = struct{}{}
+	f.mu.Unlock()
+
+	return sub
+}
+
+func (f *logFan) Unsubscribe(sub *logSubscription) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.subs[sub]; !ok {
+		return
+	}
+	delete(f.subs, sub)
+	close(sub.Lines)
+}
+
+// Publish fans a log line out to every subscriber whose cluster/service
+// filter matches (empty filter fields match everything). Subscribers
+// that can't keep up have the line dropped for them rather than blocking
+// ingestion.
+func (f *logFan) Publish(event logLineEvent) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for sub := range f.subs {
+		if sub.cluster != "" && sub.cluster != event.ClusterName {
+			continue
+		}
+		if sub.service != "" && sub.service != event.ServiceName {
+			continue
+		}
+
+		select {
+		case sub.Lines <- event:
+		default:
+		}
+	}
+}
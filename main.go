@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
@@ -8,31 +9,92 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+
+	"github.com/lovemetrue/ai-logs-traces-analyzer/internal/pipeline"
 )
 
+// otlpAuth — аутентификатор, общий для HTTP и gRPC приёмников OTLP.
+// Выбирается один раз при старте на основе переменных окружения.
+var otlpAuth = newConfiguredAuthenticator()
+
+// vectorDBClient — общий клиент векторного хранилища, используемый
+// обработчиками поиска и correlation engine.
+var vectorDBClient *VectorDBClient
+
 func main() {
-	// Запускаем два сервера:
-	// - 8080 для OTLP данных из кластера
+	shutdownTelemetry, err := initTelemetry(context.Background())
+	if err != nil {
+		log.Printf("Self-instrumentation disabled, failed to init telemetry: %v", err)
+	} else {
+		defer shutdownTelemetry(context.Background())
+	}
+
+	initIngestPipeline()
+
+	vectorDBClient, err = NewVectorDBClient(os.Getenv("VECTOR_DB_HOST"))
+	if err != nil {
+		log.Fatalf("failed to initialize vector DB client: %v", err)
+	}
+	correlationEngine = NewCorrelationEngine(vectorDBClient)
+
+	// Запускаем три сервера:
+	// - 8080 для OTLP данных из кластера (HTTP, JSON/protobuf)
+	// - 4317 для OTLP данных из кластера (gRPC, protobuf)
 	// - 8081 для UI/API запросов
-	
-	go startOTLPServer()  // порт 8080
-	startAPIServer()      // порт 8081
+
+	go startOTLPServer()             // порт 8080
+	go startOTLPGRPCServer(otlpAuth) // порт 4317
+	startAPIServer()                 // порт 8081
 }
 
 func startOTLPServer() {
 	router := gin.New()
 	router.Use(gin.Recovery())
-	
+	router.Use(otelgin.Middleware("otlp-receiver"))
+
 	// OTLP endpoints для приема данных из кластера
 	router.POST("/otlp/v1/traces", handleOTLPTraces)
 	router.POST("/otlp/v1/metrics", handleOTLPMetrics)
 	router.POST("/otlp/v1/logs", handleOTLPLogs)
-	
+
 	// Health check для otelier
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{"status": "healthy", "service": "otlp-receiver"})
 	})
 
+	// Метрики самого приёмника (ingested spans/logs/metrics, queue depth и т.д.)
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+
+	// When mTLS is configured, MTLSClusterAuthenticator (otlp-auth.go)
+	// needs a real client certificate on r.TLS — that only exists if this
+	// listener itself terminates TLS and requires one, so switch from
+	// router.Run to a TLS server that does.
+	if caFile := os.Getenv("OTLP_MTLS_CA_FILE"); caFile != "" {
+		caPEM, err := os.ReadFile(caFile)
+		if err != nil {
+			log.Fatalf("reading OTLP mTLS CA file: %v", err)
+		}
+
+		certFile, keyFile := os.Getenv("OTLP_TLS_CERT_FILE"), os.Getenv("OTLP_TLS_KEY_FILE")
+		if certFile == "" || keyFile == "" {
+			log.Fatalf("OTLP_MTLS_CA_FILE is set but OTLP_TLS_CERT_FILE/OTLP_TLS_KEY_FILE are not")
+		}
+
+		server := &http.Server{
+			Addr:      ":8080",
+			Handler:   router,
+			TLSConfig: clientTLSConfig(caPEM),
+		}
+
+		log.Printf("Starting OTLP receiver on :8080 (mTLS)")
+		if err := server.ListenAndServeTLS(certFile, keyFile); err != nil {
+			log.Fatalf("OTLP server failed: %v", err)
+		}
+		return
+	}
+
 	log.Printf("Starting OTLP receiver on :8080")
 	if err := router.Run(":8080"); err != nil {
 		log.Fatalf("OTLP server failed: %v", err)
@@ -41,91 +103,123 @@ func startOTLPServer() {
 
 func startAPIServer() {
 	router := gin.Default()
-	
+	router.Use(otelgin.Middleware("analyzer-api"))
+
 	// API endpoints для UI и ручных запросов
 	router.GET("/api/health", handleHealth)
 	router.POST("/api/analyze/incident", handleIncidentAnalysis)
+	router.POST("/api/analyze/incident/correlate", handleIncidentCorrelate)
+	router.GET("/api/analyze/incident/stream", handleIncidentAnalysisStream)
 	router.POST("/api/analyze/text", handleTextAnalysis)
+	router.GET("/api/analyze/text/stream", handleTextAnalysisStream)
 	router.GET("/api/traces/search", handleTraceSearch)
 	router.GET("/api/logs/search", handleLogSearch)
-	
+	router.GET("/api/logs/stream", handleLogStream)
+
 	// Статус системы
 	router.GET("/api/system/status", handleSystemStatus)
 
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+
 	log.Printf("Starting API server on :8081")
 	if err := router.Run(":8081"); err != nil {
 		log.Fatalf("API server failed: %v", err)
 	}
 }
 
-// Обработчик для OTLP трейсов
+// Обработчик для OTLP трейсов. Принимает и JSON (для обратной
+// совместимости со старыми клиентами), и protobuf
+// (application/x-protobuf, как у стандартных OTel SDK/коллекторов).
 func handleOTLPTraces(c *gin.Context) {
-	// Проверяем аутентификацию
-	authToken := c.GetHeader("x-auth-token")
-	if authToken != os.Getenv("OTLP_AUTH_TOKEN") {
+	clusterName, err := otlpAuth.AuthenticateHTTP(c.Request)
+	if err != nil {
 		c.JSON(401, gin.H{"error": "unauthorized"})
 		return
 	}
-	
-	clusterName := c.GetHeader("x-cluster-name")
-	
-	var traceData map[string]interface{}
-	if err := c.BindJSON(&traceData); err != nil {
+
+	traces, err := decodeOTLPTraces(c)
+	if err != nil {
 		log.Printf("Error parsing traces from cluster %s: %v", clusterName, err)
-		c.JSON(400, gin.H{"error": "invalid trace data"})
+		c.JSON(400, otlpPartialSuccess("traces", 0, err.Error()))
+		return
+	}
+
+	spanCount := traces.SpanCount()
+	log.Printf("Received traces from %s: %d spans", clusterName, spanCount)
+	ingestedSpansTotal.WithLabelValues(clusterName).Add(float64(spanCount))
+
+	if err := submitTraces(clusterName, traces); err != nil {
+		if err == pipeline.ErrQueueFull || err == pipeline.ErrRateLimited {
+			c.JSON(http.StatusTooManyRequests, otlpPartialSuccess("traces", int64(spanCount), "ingestion queue is full, retry later"))
+			return
+		}
+		log.Printf("Error buffering traces from cluster %s: %v", clusterName, err)
+		c.JSON(500, otlpPartialSuccess("traces", int64(spanCount), err.Error()))
 		return
 	}
-	
-	log.Printf("Received traces from %s: %d spans", clusterName, estimateSpanCount(traceData))
-	
-	// Асинхронная обработка
-	go processIncomingTraces(clusterName, traceData)
-	
-	c.JSON(200, gin.H{"status": "accepted"})
+
+	c.JSON(200, otlpPartialSuccess("traces", 0, ""))
 }
 
 // Обработчик для OTLP логов
 func handleOTLPLogs(c *gin.Context) {
-	authToken := c.GetHeader("x-auth-token")
-	if authToken != os.Getenv("OTLP_AUTH_TOKEN") {
+	clusterName, err := otlpAuth.AuthenticateHTTP(c.Request)
+	if err != nil {
 		c.JSON(401, gin.H{"error": "unauthorized"})
 		return
 	}
-	
-	clusterName := c.GetHeader("x-cluster-name")
-	
-	var logData map[string]interface{}
-	if err := c.BindJSON(&logData); err != nil {
+
+	logs, err := decodeOTLPLogs(c)
+	if err != nil {
 		log.Printf("Error parsing logs from cluster %s: %v", clusterName, err)
-		c.JSON(400, gin.H{"error": "invalid log data"})
+		c.JSON(400, otlpPartialSuccess("logs", 0, err.Error()))
 		return
 	}
-	
-	log.Printf("Received logs from %s: %d entries", clusterName, estimateLogCount(logData))
-	
-	go processIncomingLogs(clusterName, logData)
-	
-	c.JSON(200, gin.H{"status": "accepted"})
+
+	entryCount := logs.LogRecordCount()
+	log.Printf("Received logs from %s: %d entries", clusterName, entryCount)
+	ingestedLogsTotal.WithLabelValues(clusterName).Add(float64(entryCount))
+
+	if err := submitLogs(clusterName, logs); err != nil {
+		if err == pipeline.ErrQueueFull || err == pipeline.ErrRateLimited {
+			c.JSON(http.StatusTooManyRequests, otlpPartialSuccess("logs", int64(entryCount), "ingestion queue is full, retry later"))
+			return
+		}
+		log.Printf("Error buffering logs from cluster %s: %v", clusterName, err)
+		c.JSON(500, otlpPartialSuccess("logs", int64(entryCount), err.Error()))
+		return
+	}
+
+	c.JSON(200, otlpPartialSuccess("logs", 0, ""))
 }
 
 // Обработчик для метрик
 func handleOTLPMetrics(c *gin.Context) {
-	authToken := c.GetHeader("x-auth-token")
-	if authToken != os.Getenv("OTLP_AUTH_TOKEN") {
+	clusterName, err := otlpAuth.AuthenticateHTTP(c.Request)
+	if err != nil {
 		c.JSON(401, gin.H{"error": "unauthorized"})
 		return
 	}
-	
-	clusterName := c.GetHeader("x-cluster-name")
-	
-	var metricData map[string]interface{}
-	if err := c.BindJSON(&metricData); err != nil {
+
+	metrics, err := decodeOTLPMetrics(c)
+	if err != nil {
 		log.Printf("Error parsing metrics from cluster %s: %v", clusterName, err)
-		c.JSON(400, gin.H{"error": "invalid metric data"})
+		c.JSON(400, otlpPartialSuccess("metrics", 0, err.Error()))
 		return
 	}
-	
-	go processIncomingMetrics(clusterName, metricData)
-	
-	c.JSON(200, gin.H{"status": "accepted"})
+
+	pointCount := metrics.DataPointCount()
+	ingestedMetricsTotal.WithLabelValues(clusterName).Add(float64(pointCount))
+
+	if err := submitMetrics(clusterName, metrics); err != nil {
+		if err == pipeline.ErrQueueFull || err == pipeline.ErrRateLimited {
+			c.JSON(http.StatusTooManyRequests, otlpPartialSuccess("metrics", int64(pointCount), "ingestion queue is full, retry later"))
+			return
+		}
+		log.Printf("Error buffering metrics from cluster %s: %v", clusterName, err)
+		c.JSON(500, otlpPartialSuccess("metrics", int64(pointCount), err.Error()))
+		return
+	}
+
+	c.JSON(200, otlpPartialSuccess("metrics", 0, ""))
 }
\ No newline at end of file
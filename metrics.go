@@ -0,0 +1,50 @@
+// metrics.go
+package main
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Метрики самого анализатора, публикуемые на /metrics. Разбиты по
+// кластеру (x-cluster-name), чтобы было видно, кто из клиентов шлёт
+// нагрузку и где узкое место — в приёме, в эмбеддинге или в векторном
+// хранилище.
+var (
+	ingestedSpansTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "analyzer_ingested_spans_total",
+		Help: "Total number of spans ingested, by cluster.",
+	}, []string{"cluster"})
+
+	ingestedLogsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "analyzer_ingested_log_entries_total",
+		Help: "Total number of log entries ingested, by cluster.",
+	}, []string{"cluster"})
+
+	ingestedMetricsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "analyzer_ingested_metric_points_total",
+		Help: "Total number of metric data points ingested, by cluster.",
+	}, []string{"cluster"})
+
+	embeddingLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "analyzer_embedding_latency_seconds",
+		Help:    "Latency of embedding calls; every call is recorded under provider=\"default\".",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"provider"})
+
+	vectorDBCallLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "analyzer_vector_db_call_latency_seconds",
+		Help:    "Latency of calls to the vector store, by operation.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"operation"})
+
+	ingestionQueueDepth = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "analyzer_ingestion_queue_depth",
+		Help: "Length of the shared async ingestion queue, as sampled when a job of this signal type was last enqueued.",
+	}, []string{"signal"})
+
+	ingestionDroppedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "analyzer_ingestion_dropped_total",
+		Help: "Total number of OTLP payloads rejected because the ingestion queue was full, by signal type.",
+	}, []string{"signal"})
+)
@@ -0,0 +1,245 @@
+// otlp-auth.go
+package main
+
+import (
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Authenticator проверяет, что запрос действительно пришёл от заявленного
+// кластера, прежде чем мы примем его трейсы/логи/метрики в обработку.
+type Authenticator interface {
+	// AuthenticateHTTP проверяет входящий HTTP-запрос и возвращает имя
+	// кластера, которому разрешено отправлять данные под этим запросом.
+	AuthenticateHTTP(r *http.Request) (clusterName string, err error)
+}
+
+// SharedSecretAuthenticator — старое поведение (x-auth-token), оставлено
+// как fallback для кластеров, которые ещё не перешли на JWT/mTLS.
+type SharedSecretAuthenticator struct {
+	token string
+}
+
+func NewSharedSecretAuthenticator() *SharedSecretAuthenticator {
+	return &SharedSecretAuthenticator{token: os.Getenv("OTLP_AUTH_TOKEN")}
+}
+
+func (a *SharedSecretAuthenticator) AuthenticateHTTP(r *http.Request) (string, error) {
+	if r.Header.Get("x-auth-token") != a.token {
+		return "", fmt.Errorf("invalid x-auth-token")
+	}
+	return r.Header.Get("x-cluster-name"), nil
+}
+
+// JWKSBearerAuthenticator проверяет bearer JWT против ключей, полученных
+// с JWKS URL, и сверяет claim "cluster" с заголовком x-cluster-name, чтобы
+// один тенант не мог представиться другим.
+type JWKSBearerAuthenticator struct {
+	jwksURL string
+
+	mu        sync.Mutex
+	keys      map[string]interface{}
+	fetchedAt time.Time
+	ttl       time.Duration
+}
+
+func NewJWKSBearerAuthenticator(jwksURL string) *JWKSBearerAuthenticator {
+	return &JWKSBearerAuthenticator{jwksURL: jwksURL, ttl: 10 * time.Minute}
+}
+
+func (a *JWKSBearerAuthenticator) AuthenticateHTTP(r *http.Request) (string, error) {
+	raw := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if raw == "" {
+		return "", fmt.Errorf("missing bearer token")
+	}
+
+	keys, err := a.keyset()
+	if err != nil {
+		return "", fmt.Errorf("fetching jwks: %w", err)
+	}
+
+	token, err := jwt.Parse(raw, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		key, ok := keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown key id %q", kid)
+		}
+		return key, nil
+	}, jwt.WithValidMethods([]string{"RS256"}))
+	if err != nil || !token.Valid {
+		return "", fmt.Errorf("invalid token: %w", err)
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return "", fmt.Errorf("unexpected claims type")
+	}
+
+	claimedCluster, _ := claims["cluster"].(string)
+	headerCluster := r.Header.Get("x-cluster-name")
+	if claimedCluster == "" || claimedCluster != headerCluster {
+		return "", fmt.Errorf("token cluster %q does not match x-cluster-name %q", claimedCluster, headerCluster)
+	}
+
+	return claimedCluster, nil
+}
+
+// keyset возвращает кэшированный набор ключей JWKS, обновляя его не чаще
+// раза в a.ttl.
+func (a *JWKSBearerAuthenticator) keyset() (map[string]interface{}, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.keys != nil && time.Since(a.fetchedAt) < a.ttl {
+		return a.keys, nil
+	}
+
+	keys, err := fetchJWKS(a.jwksURL)
+	if err != nil {
+		return nil, err
+	}
+
+	a.keys = keys
+	a.fetchedAt = time.Now()
+	return a.keys, nil
+}
+
+// MTLSClusterAuthenticator доверяет клиентскому сертификату mTLS: CN
+// сертификата должен совпадать с x-cluster-name, иначе запрос отклоняется.
+type MTLSClusterAuthenticator struct {
+	pool *x509.CertPool
+}
+
+func NewMTLSClusterAuthenticator(caPEM []byte) (*MTLSClusterAuthenticator, error) {
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("no valid certificates in CA bundle")
+	}
+	return &MTLSClusterAuthenticator{pool: pool}, nil
+}
+
+func (a *MTLSClusterAuthenticator) AuthenticateHTTP(r *http.Request) (string, error) {
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return "", fmt.Errorf("no client certificate presented")
+	}
+
+	leaf := r.TLS.PeerCertificates[0]
+	opts := x509.VerifyOptions{Roots: a.pool, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}}
+	if _, err := leaf.Verify(opts); err != nil {
+		return "", fmt.Errorf("client certificate verification failed: %w", err)
+	}
+
+	headerCluster := r.Header.Get("x-cluster-name")
+	if leaf.Subject.CommonName != headerCluster {
+		return "", fmt.Errorf("certificate CN %q does not match x-cluster-name %q", leaf.Subject.CommonName, headerCluster)
+	}
+
+	return headerCluster, nil
+}
+
+// newConfiguredAuthenticator выбирает аутентификатор на основе переменных
+// окружения, чтобы не ломать развёртывания, которые ещё используют
+// OTLP_AUTH_TOKEN. mTLS берёт верх, если задан OTLP_MTLS_CA_FILE: в этом
+// режиме startOTLPServer/startOTLPGRPCServer и так требуют клиентский
+// сертификат на транспортном уровне, так что разумно доверять именно его
+// CN, а не отдельному токену/JWT поверх уже аутентифицированного канала.
+func newConfiguredAuthenticator() Authenticator {
+	if caFile := os.Getenv("OTLP_MTLS_CA_FILE"); caFile != "" {
+		caPEM, err := os.ReadFile(caFile)
+		if err != nil {
+			log.Fatalf("reading OTLP mTLS CA file: %v", err)
+		}
+		auth, err := NewMTLSClusterAuthenticator(caPEM)
+		if err != nil {
+			log.Fatalf("configuring mTLS authenticator: %v", err)
+		}
+		return auth
+	}
+	if jwksURL := os.Getenv("OTLP_JWKS_URL"); jwksURL != "" {
+		return NewJWKSBearerAuthenticator(jwksURL)
+	}
+	return NewSharedSecretAuthenticator()
+}
+
+// clientTLSConfig строит tls.Config для сервера, требующего клиентский
+// сертификат, когда включён режим mTLS (OTLP_MTLS_CA_FILE задан).
+func clientTLSConfig(caPEM []byte) *tls.Config {
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(caPEM)
+	return &tls.Config{
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  pool,
+	}
+}
+
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type jwks struct {
+	Keys []jwk `json:"keys"`
+}
+
+// fetchJWKS скачивает и разбирает JWKS-документ в map kid -> *rsa.PublicKey.
+// Поддерживаются только RSA-ключи, что покрывает все известные нам IdP.
+func fetchJWKS(url string) (map[string]interface{}, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("jwks endpoint returned %d", resp.StatusCode)
+	}
+
+	var doc jwks
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("decoding jwks: %w", err)
+	}
+
+	keys := make(map[string]interface{}, len(doc.Keys))
+	for _, k := range doc.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		pub, err := rsaPublicKeyFromJWK(k)
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = pub
+	}
+	return keys, nil
+}
+
+func rsaPublicKeyFromJWK(k jwk) (*rsa.PublicKey, error) {
+	nBytes, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, err
+	}
+	eBytes, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(nBytes),
+		E: int(new(big.Int).SetBytes(eBytes).Int64()),
+	}, nil
+}
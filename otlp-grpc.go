@@ -0,0 +1,194 @@
+// otlp-grpc.go
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+
+	"go.opentelemetry.io/collector/pdata/plog/plogotlp"
+	"go.opentelemetry.io/collector/pdata/pmetric/pmetricotlp"
+	"go.opentelemetry.io/collector/pdata/ptrace/ptraceotlp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/lovemetrue/ai-logs-traces-analyzer/internal/pipeline"
+)
+
+// fakeHeaderCarrier позволяет переиспользовать Authenticator.AuthenticateHTTP,
+// написанный для net/http, поверх gRPC-метаданных, чтобы у HTTP и gRPC
+// путей приёма OTLP была ровно одна реализация проверки доступа.
+type fakeHeaderCarrier struct {
+	md metadata.MD
+}
+
+func (f *fakeHeaderCarrier) asRequest() *http.Request {
+	req := &http.Request{Header: http.Header{}}
+	for k, vs := range f.md {
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+	return req
+}
+
+// grpcAuth is embedded by all three OTLP gRPC server types below and
+// centralizes the gRPC-metadata -> Authenticator bridge, since each of
+// them needs exactly the same cluster-auth check.
+type grpcAuth struct {
+	auth Authenticator
+}
+
+// clusterFromContext достаёт x-cluster-name из gRPC-метаданных и
+// авторизует запрос тем же Authenticator, что используется HTTP-путём.
+func (g *grpcAuth) clusterFromContext(ctx context.Context) (string, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", status.Error(codes.Unauthenticated, "missing metadata")
+	}
+
+	fake := &fakeHeaderCarrier{md: md}
+	req := fake.asRequest()
+	// Carry the connection's TLS state over as well, so that
+	// MTLSClusterAuthenticator sees the client certificate on this path.
+	if p, ok := peer.FromContext(ctx); ok {
+		if tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			req.TLS = &tlsInfo.State
+		}
+	}
+	cluster, err := g.auth.AuthenticateHTTP(req)
+	if err != nil {
+		return "", status.Errorf(codes.Unauthenticated, "auth failed: %v", err)
+	}
+	return cluster, nil
+}
+
+// otlpTraceServer, otlpLogsServer and otlpMetricsServer are three
+// distinct types rather than one struct implementing all three
+// services: ptraceotlp/plogotlp/pmetricotlp.GRPCServer each require a
+// method named exactly "Export" with a different signature, so a single
+// type can only ever satisfy one of them.
+type otlpTraceServer struct {
+	ptraceotlp.UnimplementedGRPCServer
+	grpcAuth
+}
+
+type otlpLogsServer struct {
+	plogotlp.UnimplementedGRPCServer
+	grpcAuth
+}
+
+type otlpMetricsServer struct {
+	pmetricotlp.UnimplementedGRPCServer
+	grpcAuth
+}
+
+func (s *otlpTraceServer) Export(ctx context.Context, req ptraceotlp.ExportRequest) (ptraceotlp.ExportResponse, error) {
+	cluster, err := s.clusterFromContext(ctx)
+	if err != nil {
+		return ptraceotlp.NewExportResponse(), err
+	}
+
+	spanCount := req.Traces().SpanCount()
+	log.Printf("gRPC: received %d spans from %s", spanCount, cluster)
+	ingestedSpansTotal.WithLabelValues(cluster).Add(float64(spanCount))
+
+	if err := submitTraces(cluster, req.Traces()); err != nil {
+		return ptraceotlp.NewExportResponse(), grpcBackpressureError(err)
+	}
+
+	return ptraceotlp.NewExportResponse(), nil
+}
+
+func (s *otlpLogsServer) Export(ctx context.Context, req plogotlp.ExportRequest) (plogotlp.ExportResponse, error) {
+	cluster, err := s.clusterFromContext(ctx)
+	if err != nil {
+		return plogotlp.NewExportResponse(), err
+	}
+
+	ingestedLogsTotal.WithLabelValues(cluster).Add(float64(req.Logs().LogRecordCount()))
+
+	if err := submitLogs(cluster, req.Logs()); err != nil {
+		return plogotlp.NewExportResponse(), grpcBackpressureError(err)
+	}
+
+	return plogotlp.NewExportResponse(), nil
+}
+
+func (s *otlpMetricsServer) Export(ctx context.Context, req pmetricotlp.ExportRequest) (pmetricotlp.ExportResponse, error) {
+	cluster, err := s.clusterFromContext(ctx)
+	if err != nil {
+		return pmetricotlp.NewExportResponse(), err
+	}
+
+	ingestedMetricsTotal.WithLabelValues(cluster).Add(float64(req.Metrics().DataPointCount()))
+
+	if err := submitMetrics(cluster, req.Metrics()); err != nil {
+		return pmetricotlp.NewExportResponse(), grpcBackpressureError(err)
+	}
+
+	return pmetricotlp.NewExportResponse(), nil
+}
+
+// grpcBackpressureError maps a full ingestion queue or an exceeded
+// per-cluster rate limit to codes.ResourceExhausted (the gRPC equivalent of
+// HTTP 429) so OTLP SDKs retry with backoff instead of treating it as a
+// permanent failure.
+func grpcBackpressureError(err error) error {
+	if err == pipeline.ErrQueueFull || err == pipeline.ErrRateLimited {
+		return status.Error(codes.ResourceExhausted, "ingestion queue is full, retry later")
+	}
+	return status.Errorf(codes.Internal, "buffering payload: %v", err)
+}
+
+// startOTLPGRPCServer поднимает gRPC-приёмник OTLP на OTLP_GRPC_PORT
+// (по умолчанию :4317, как у стандартного Collector), опционально с mTLS.
+func startOTLPGRPCServer(auth Authenticator) {
+	port := os.Getenv("OTLP_GRPC_PORT")
+	if port == "" {
+		port = "4317"
+	}
+
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%s", port))
+	if err != nil {
+		log.Fatalf("OTLP gRPC listener failed: %v", err)
+	}
+
+	var opts []grpc.ServerOption
+	if caFile := os.Getenv("OTLP_MTLS_CA_FILE"); caFile != "" {
+		cert, err := tls.LoadX509KeyPair(os.Getenv("OTLP_TLS_CERT_FILE"), os.Getenv("OTLP_TLS_KEY_FILE"))
+		if err != nil {
+			log.Fatalf("loading OTLP gRPC TLS certificate: %v", err)
+		}
+		caPEM, err := os.ReadFile(caFile)
+		if err != nil {
+			log.Fatalf("reading OTLP mTLS CA file: %v", err)
+		}
+
+		// clientTLSConfig (otlp-auth.go) sets ClientAuth/ClientCAs so the
+		// client certificate is actually required and verified, not just
+		// server TLS with no client-cert pinning.
+		tlsConfig := clientTLSConfig(caPEM)
+		tlsConfig.Certificates = []tls.Certificate{cert}
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+
+	server := grpc.NewServer(opts...)
+
+	ptraceotlp.RegisterGRPCServer(server, &otlpTraceServer{grpcAuth: grpcAuth{auth: auth}})
+	plogotlp.RegisterGRPCServer(server, &otlpLogsServer{grpcAuth: grpcAuth{auth: auth}})
+	pmetricotlp.RegisterGRPCServer(server, &otlpMetricsServer{grpcAuth: grpcAuth{auth: auth}})
+
+	log.Printf("Starting OTLP gRPC receiver on :%s", port)
+	if err := server.Serve(lis); err != nil {
+		log.Fatalf("OTLP gRPC server failed: %v", err)
+	}
+}
@@ -0,0 +1,187 @@
+// otlp-http.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// decodeOTLPTraces разбирает тело запроса как protobuf
+// ExportTraceServiceRequest, если Content-Type — application/x-protobuf,
+// и как OTLP/JSON в остальных случаях, чтобы не ломать старых клиентов.
+func decodeOTLPTraces(c *gin.Context) (ptrace.Traces, error) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return ptrace.Traces{}, fmt.Errorf("reading body: %w", err)
+	}
+
+	unmarshaler := tracesUnmarshalerFor(c.ContentType())
+	traces, err := unmarshaler.UnmarshalTraces(body)
+	if err != nil {
+		return ptrace.Traces{}, fmt.Errorf("unmarshaling traces: %w", err)
+	}
+	return traces, nil
+}
+
+func decodeOTLPLogs(c *gin.Context) (plog.Logs, error) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return plog.Logs{}, fmt.Errorf("reading body: %w", err)
+	}
+
+	unmarshaler := logsUnmarshalerFor(c.ContentType())
+	logs, err := unmarshaler.UnmarshalLogs(body)
+	if err != nil {
+		return plog.Logs{}, fmt.Errorf("unmarshaling logs: %w", err)
+	}
+	return logs, nil
+}
+
+func decodeOTLPMetrics(c *gin.Context) (pmetric.Metrics, error) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return pmetric.Metrics{}, fmt.Errorf("reading body: %w", err)
+	}
+
+	unmarshaler := metricsUnmarshalerFor(c.ContentType())
+	metrics, err := unmarshaler.UnmarshalMetrics(body)
+	if err != nil {
+		return pmetric.Metrics{}, fmt.Errorf("unmarshaling metrics: %w", err)
+	}
+	return metrics, nil
+}
+
+func tracesUnmarshalerFor(contentType string) ptrace.Unmarshaler {
+	if contentType == "application/x-protobuf" {
+		return &ptrace.ProtoUnmarshaler{}
+	}
+	return &ptrace.JSONUnmarshaler{}
+}
+
+func logsUnmarshalerFor(contentType string) plog.Unmarshaler {
+	if contentType == "application/x-protobuf" {
+		return &plog.ProtoUnmarshaler{}
+	}
+	return &plog.JSONUnmarshaler{}
+}
+
+func metricsUnmarshalerFor(contentType string) pmetric.Unmarshaler {
+	if contentType == "application/x-protobuf" {
+		return &pmetric.ProtoUnmarshaler{}
+	}
+	return &pmetric.JSONUnmarshaler{}
+}
+
+// otlpRejectedCountField maps a signal type to the field name OTLP SDKs
+// expect in ExportPartialSuccess for that signal — they aren't
+// interchangeable, so reporting rejected log records under
+// "rejectedSpans" would leave an OTLP log exporter unable to see the
+// rejection at all.
+func otlpRejectedCountField(signal string) string {
+	switch signal {
+	case "logs":
+		return "rejectedLogRecords"
+	case "metrics":
+		return "rejectedDataPoints"
+	default:
+		return "rejectedSpans"
+	}
+}
+
+// otlpPartialSuccess строит тело ответа в форме, которую ожидают OTLP SDK:
+// пустой "partialSuccess" означает, что всё принято; rejectedCount > 0
+// сигнализирует клиенту, что часть payload'а не была обработана.
+func otlpPartialSuccess(signal string, rejectedCount int64, errorMessage string) gin.H {
+	if rejectedCount == 0 && errorMessage == "" {
+		return gin.H{"partialSuccess": gin.H{}}
+	}
+	return gin.H{
+		"partialSuccess": gin.H{
+			otlpRejectedCountField(signal): rejectedCount,
+			"errorMessage":                 errorMessage,
+		},
+	}
+}
+
+// processIncomingTraceData — эквивалент processIncomingTraces, но работающий
+// над pdata.Traces, полученными из protobuf/gRPC, а не над map[string]interface{}.
+func processIncomingTraceData(clusterName string, traces ptrace.Traces) {
+	processIncomingTraces(clusterName, pdataTracesToLegacyMap(traces))
+}
+
+func processIncomingLogData(clusterName string, logs plog.Logs) {
+	publishLogsToFanout(clusterName, logs)
+	processIncomingLogs(clusterName, pdataLogsToLegacyMap(logs))
+}
+
+// publishLogsToFanout feeds every accepted log record to logFanout so
+// GET /api/logs/stream subscribers see it in real time, independent of
+// how long processIncomingLogs takes to persist it.
+func publishLogsToFanout(clusterName string, logs plog.Logs) {
+	for i := 0; i < logs.ResourceLogs().Len(); i++ {
+		resourceLogs := logs.ResourceLogs().At(i)
+		serviceName, _ := resourceLogs.Resource().Attributes().Get("service.name")
+
+		for j := 0; j < resourceLogs.ScopeLogs().Len(); j++ {
+			scopeLogs := resourceLogs.ScopeLogs().At(j)
+			for k := 0; k < scopeLogs.LogRecords().Len(); k++ {
+				record := scopeLogs.LogRecords().At(k)
+				logFanout.Publish(logLineEvent{
+					ClusterName: clusterName,
+					ServiceName: serviceName.AsString(),
+					Message:     record.Body().AsString(),
+				})
+			}
+		}
+	}
+}
+
+func processIncomingMetricData(clusterName string, metrics pmetric.Metrics) {
+	processIncomingMetrics(clusterName, pdataMetricsToLegacyMap(metrics))
+}
+
+// Мосты ниже существуют только для переходного периода, пока
+// processTracesForVectorDB и соседний код в vector-db.go не переведены на
+// pdata напрямую. Как только это случится, этот файл и легаси-путь на
+// map[string]interface{} можно будет удалить.
+
+func pdataTracesToLegacyMap(traces ptrace.Traces) map[string]interface{} {
+	marshaler := &ptrace.JSONMarshaler{}
+	data, err := marshaler.MarshalTraces(traces)
+	if err != nil {
+		return map[string]interface{}{}
+	}
+	return jsonBytesToMap(data)
+}
+
+func pdataLogsToLegacyMap(logs plog.Logs) map[string]interface{} {
+	marshaler := &plog.JSONMarshaler{}
+	data, err := marshaler.MarshalLogs(logs)
+	if err != nil {
+		return map[string]interface{}{}
+	}
+	return jsonBytesToMap(data)
+}
+
+func pdataMetricsToLegacyMap(metrics pmetric.Metrics) map[string]interface{} {
+	marshaler := &pmetric.JSONMarshaler{}
+	data, err := marshaler.MarshalMetrics(metrics)
+	if err != nil {
+		return map[string]interface{}{}
+	}
+	return jsonBytesToMap(data)
+}
+
+func jsonBytesToMap(data []byte) map[string]interface{} {
+	var out map[string]interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return map[string]interface{}{}
+	}
+	return out
+}
@@ -0,0 +1,201 @@
+// pgvector-store.go
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pgvector/pgvector-go"
+)
+
+// pgvectorStore stores everything in one table, partitioned by a
+// "cluster" column rather than a table per tenant — Postgres handles
+// row-level filtering cheaply and this avoids a migration per new
+// cluster, unlike the collection-per-cluster schemes the other backends
+// use.
+type pgvectorStore struct {
+	pool *pgxpool.Pool
+}
+
+func newPgVectorStore(dsn string) (*pgvectorStore, error) {
+	pool, err := pgxpool.New(context.Background(), dsn)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to postgres: %w", err)
+	}
+
+	// embedding's width must track embeddingDimensions (see vector-store.go):
+	// it's configurable via EMBEDDING_DIMENSIONS for backends like OpenAI's
+	// text-embedding-3-small (1536 dims) that don't use the 384-dim default,
+	// and Qdrant already derives its collection's vector size the same way.
+	if _, err := pool.Exec(context.Background(), fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS trace_embeddings (
+			id          TEXT PRIMARY KEY,
+			cluster     TEXT NOT NULL,
+			kind        TEXT NOT NULL DEFAULT 'trace',
+			document    TEXT NOT NULL,
+			metadata    JSONB NOT NULL,
+			service_name TEXT,
+			span_kind   TEXT,
+			observed_at TIMESTAMPTZ,
+			embedding   vector(%d)
+		)
+	`, embeddingDimensions)); err != nil {
+		return nil, fmt.Errorf("ensuring trace_embeddings table: %w", err)
+	}
+
+	// CREATE TABLE IF NOT EXISTS is a no-op on a deployment that already
+	// had trace_embeddings from before kind existed, so add it separately
+	// rather than relying on the table body above.
+	if _, err := pool.Exec(context.Background(), `
+		ALTER TABLE trace_embeddings ADD COLUMN IF NOT EXISTS kind TEXT NOT NULL DEFAULT 'trace'
+	`); err != nil {
+		return nil, fmt.Errorf("ensuring trace_embeddings.kind column: %w", err)
+	}
+
+	return &pgvectorStore{pool: pool}, nil
+}
+
+func (s *pgvectorStore) SaveTraces(ctx context.Context, cluster string, batch VectorBatch) error {
+	return s.upsertBatch(ctx, cluster, "trace", batch)
+}
+
+func (s *pgvectorStore) SaveLogs(ctx context.Context, cluster string, batch VectorBatch) error {
+	// Logs share trace_embeddings with traces rather than a table of
+	// their own; span_kind is simply absent from log metadata, and
+	// SearchSimilar's filters already treat an unset axis as "don't care".
+	// kind is what lets ListTracesInWindow/ListLogsInWindow tell the two
+	// apart again.
+	return s.upsertBatch(ctx, cluster, "log", batch)
+}
+
+func (s *pgvectorStore) upsertBatch(ctx context.Context, cluster, kind string, batch VectorBatch) error {
+	pgBatch := &pgx.Batch{}
+	for i := range batch.IDs {
+		metadataJSON, err := json.Marshal(batch.Metadatas[i])
+		if err != nil {
+			return fmt.Errorf("marshaling metadata: %w", err)
+		}
+
+		pgBatch.Queue(`
+			INSERT INTO trace_embeddings (id, cluster, kind, document, metadata, service_name, span_kind, observed_at, embedding)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			ON CONFLICT (id) DO UPDATE SET document = EXCLUDED.document, embedding = EXCLUDED.embedding, observed_at = EXCLUDED.observed_at
+		`, batch.IDs[i], cluster, kind, batch.Documents[i], metadataJSON,
+			batch.Metadatas[i]["service_name"], batch.Metadatas[i]["span_kind"],
+			pgvectorObservedAt(batch.Metadatas[i]), pgvector.NewVector(batch.Embeddings[i]))
+	}
+
+	return s.pool.SendBatch(ctx, pgBatch).Close()
+}
+
+// pgvectorObservedAt pulls the span/log timestamp out of metadata so
+// SearchSimilar's From/To range filters (which query observed_at) have
+// something to filter on — left unpopulated, every time-bounded search
+// would silently come back empty. timestamp is stored in metadata as
+// Unix nanoseconds, matching the "timestamp" field the other backends
+// already filter on (see qdrantFilterFrom, chromaWhereClause).
+func pgvectorObservedAt(metadata map[string]interface{}) *time.Time {
+	switch ts := metadata["timestamp"].(type) {
+	case int64:
+		t := time.Unix(0, ts)
+		return &t
+	case float64:
+		t := time.Unix(0, int64(ts))
+		return &t
+	default:
+		return nil
+	}
+}
+
+func (s *pgvectorStore) SearchSimilar(ctx context.Context, queryEmbedding []float32, limit int, filter SearchFilter) ([]SearchResult, error) {
+	query := `
+		SELECT document, 1 - (embedding <=> $1) AS score
+		FROM trace_embeddings
+		WHERE cluster = $2
+	`
+	args := []interface{}{pgvector.NewVector(queryEmbedding), filter.Cluster}
+
+	if filter.ServiceName != "" {
+		args = append(args, filter.ServiceName)
+		query += fmt.Sprintf(" AND service_name = $%d", len(args))
+	}
+	if filter.SpanKind != "" {
+		args = append(args, filter.SpanKind)
+		query += fmt.Sprintf(" AND span_kind = $%d", len(args))
+	}
+	if !filter.From.IsZero() {
+		args = append(args, filter.From)
+		query += fmt.Sprintf(" AND observed_at >= $%d", len(args))
+	}
+	if !filter.To.IsZero() {
+		args = append(args, filter.To)
+		query += fmt.Sprintf(" AND observed_at <= $%d", len(args))
+	}
+
+	args = append(args, limit)
+	query += fmt.Sprintf(" ORDER BY embedding <=> $1 LIMIT $%d", len(args))
+
+	rows, err := s.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []SearchResult
+	for rows.Next() {
+		var r SearchResult
+		if err := rows.Scan(&r.Document, &r.Score); err != nil {
+			return nil, err
+		}
+		results = append(results, r)
+	}
+	return results, rows.Err()
+}
+
+func (s *pgvectorStore) ListTracesInWindow(ctx context.Context, cluster string, from, to time.Time) ([]VectorRecord, error) {
+	return s.listInWindow(ctx, cluster, "trace", from, to)
+}
+
+func (s *pgvectorStore) ListLogsInWindow(ctx context.Context, cluster string, from, to time.Time) ([]VectorRecord, error) {
+	return s.listInWindow(ctx, cluster, "log", from, to)
+}
+
+func (s *pgvectorStore) listInWindow(ctx context.Context, cluster, kind string, from, to time.Time) ([]VectorRecord, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT id, document, metadata
+		FROM trace_embeddings
+		WHERE cluster = $1 AND kind = $2 AND observed_at >= $3 AND observed_at <= $4
+	`, cluster, kind, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []VectorRecord
+	for rows.Next() {
+		var r VectorRecord
+		var metadataJSON []byte
+		if err := rows.Scan(&r.ID, &r.Document, &metadataJSON); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(metadataJSON, &r.Metadata); err != nil {
+			return nil, fmt.Errorf("unmarshaling metadata: %w", err)
+		}
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
+
+func (s *pgvectorStore) DeleteByCluster(ctx context.Context, cluster string) error {
+	_, err := s.pool.Exec(ctx, `DELETE FROM trace_embeddings WHERE cluster = $1`, cluster)
+	return err
+}
+
+func (s *pgvectorStore) Compact(ctx context.Context) error {
+	_, err := s.pool.Exec(ctx, `VACUUM ANALYZE trace_embeddings`)
+	return err
+}
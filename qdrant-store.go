@@ -0,0 +1,293 @@
+// qdrant-store.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"github.com/qdrant/go-client/qdrant"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// qdrantStore talks to Qdrant over gRPC. Each cluster gets its own
+// collection (see collectionForCluster), created lazily on first write.
+type qdrantStore struct {
+	conn       *grpc.ClientConn
+	points     qdrant.PointsClient
+	collection qdrant.CollectionsClient
+}
+
+func newQdrantStore(host string) (*qdrantStore, error) {
+	conn, err := grpc.Dial(host, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("dialing qdrant at %s: %w", host, err)
+	}
+
+	return &qdrantStore{
+		conn:       conn,
+		points:     qdrant.NewPointsClient(conn),
+		collection: qdrant.NewCollectionsClient(conn),
+	}, nil
+}
+
+func (s *qdrantStore) ensureCollection(ctx context.Context, name string) error {
+	_, err := s.collection.Create(ctx, &qdrant.CreateCollection{
+		CollectionName: name,
+		VectorsConfig: &qdrant.VectorsConfig{
+			Config: &qdrant.VectorsConfig_Params{
+				Params: &qdrant.VectorParams{
+					Size:     embeddingDimensions,
+					Distance: qdrant.Distance_Cosine,
+				},
+			},
+		},
+	})
+	if err != nil && !isAlreadyExists(err) {
+		return err
+	}
+	return nil
+}
+
+func (s *qdrantStore) SaveTraces(ctx context.Context, cluster string, batch VectorBatch) error {
+	collection := collectionForCluster(cluster)
+	if err := s.ensureCollection(ctx, collection); err != nil {
+		return err
+	}
+	return s.upsertBatch(ctx, collection, batch)
+}
+
+func (s *qdrantStore) SaveLogs(ctx context.Context, cluster string, batch VectorBatch) error {
+	collection := collectionForCluster(cluster) + "_logs"
+	if err := s.ensureCollection(ctx, collection); err != nil {
+		return err
+	}
+	return s.upsertBatch(ctx, collection, batch)
+}
+
+func (s *qdrantStore) upsertBatch(ctx context.Context, collection string, batch VectorBatch) error {
+	points := make([]*qdrant.PointStruct, 0, len(batch.IDs))
+	for i := range batch.IDs {
+		payload := qdrantPayloadFromMetadata(batch.Metadatas[i], batch.Documents[i])
+		points = append(points, &qdrant.PointStruct{
+			Id:      qdrantPointIDFrom(batch.IDs[i]),
+			Vectors: qdrant.NewVectors(batch.Embeddings[i]...),
+			Payload: payload,
+		})
+	}
+
+	_, err := s.points.Upsert(ctx, &qdrant.UpsertPoints{
+		CollectionName: collection,
+		Points:         points,
+	})
+	return err
+}
+
+func (s *qdrantStore) SearchSimilar(ctx context.Context, queryEmbedding []float32, limit int, filter SearchFilter) ([]SearchResult, error) {
+	resp, err := s.points.Search(ctx, &qdrant.SearchPoints{
+		CollectionName: collectionForCluster(filter.Cluster),
+		Vector:         queryEmbedding,
+		Limit:          uint64(limit),
+		Filter:         qdrantFilterFrom(filter),
+		WithPayload:    qdrant.NewWithPayloadEnable(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return qdrantResultsToSearchResults(resp.GetResult()), nil
+}
+
+func (s *qdrantStore) ListTracesInWindow(ctx context.Context, cluster string, from, to time.Time) ([]VectorRecord, error) {
+	return s.listInWindow(ctx, collectionForCluster(cluster), from, to)
+}
+
+func (s *qdrantStore) ListLogsInWindow(ctx context.Context, cluster string, from, to time.Time) ([]VectorRecord, error) {
+	return s.listInWindow(ctx, collectionForCluster(cluster)+"_logs", from, to)
+}
+
+// listInWindow uses Scroll rather than Search: a window scan has no query
+// vector to rank against, only a payload filter, and Scroll is Qdrant's
+// paging primitive for exactly that case.
+func (s *qdrantStore) listInWindow(ctx context.Context, collection string, from, to time.Time) ([]VectorRecord, error) {
+	var records []VectorRecord
+	var offset *qdrant.PointId
+
+	for {
+		resp, err := s.points.Scroll(ctx, &qdrant.ScrollPoints{
+			CollectionName: collection,
+			Filter:         qdrantFilterFrom(SearchFilter{From: from, To: to}),
+			WithPayload:    qdrant.NewWithPayloadEnable(true),
+			Offset:         offset,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		points := resp.GetResult()
+		for _, p := range points {
+			records = append(records, qdrantPointToRecord(p))
+		}
+
+		offset = resp.GetNextPageOffset()
+		if offset == nil || len(points) == 0 {
+			return records, nil
+		}
+	}
+}
+
+func (s *qdrantStore) DeleteByCluster(ctx context.Context, cluster string) error {
+	_, err := s.collection.Delete(ctx, &qdrant.DeleteCollection{CollectionName: collectionForCluster(cluster)})
+	return err
+}
+
+func (s *qdrantStore) Compact(ctx context.Context) error {
+	// Qdrant compacts segments on its own optimizer schedule; no manual
+	// trigger is exposed over the client API we use here.
+	return nil
+}
+
+// qdrantFilterFrom maps SearchFilter onto Qdrant's payload filter DSL.
+func qdrantFilterFrom(filter SearchFilter) *qdrant.Filter {
+	var conditions []*qdrant.Condition
+	if filter.ServiceName != "" {
+		conditions = append(conditions, qdrant.NewMatch("service_name", filter.ServiceName))
+	}
+	if filter.SpanKind != "" {
+		conditions = append(conditions, qdrant.NewMatch("span_kind", filter.SpanKind))
+	}
+	if !filter.From.IsZero() || !filter.To.IsZero() {
+		r := &qdrant.Range{}
+		if !filter.From.IsZero() {
+			from := float64(filter.From.UnixNano())
+			r.Gte = &from
+		}
+		if !filter.To.IsZero() {
+			to := float64(filter.To.UnixNano())
+			r.Lte = &to
+		}
+		conditions = append(conditions, qdrant.NewRange("timestamp", r))
+	}
+	if len(conditions) == 0 {
+		return nil
+	}
+	return &qdrant.Filter{Must: conditions}
+}
+
+// qdrantPointIDFrom derives a stable numeric point ID from a trace/span ID
+// string, since Qdrant point IDs must be either a uint64 or a UUID and our
+// IDs are neither. Hashing (rather than using the slice index, as before)
+// makes repeated upserts of the same span idempotent instead of minting a
+// new point every time.
+func qdrantPointIDFrom(id string) *qdrant.PointId {
+	h := fnv.New64a()
+	h.Write([]byte(id))
+	return qdrant.NewIDNum(h.Sum64())
+}
+
+func isAlreadyExists(err error) bool {
+	st, ok := status.FromError(err)
+	return ok && st.Code() == codes.AlreadyExists
+}
+
+// qdrantPayloadFromMetadata encodes every metadata value we actually store
+// (strings, numbers and bools — e.g. "timestamp" as an int64/float64,
+// "is_error" as a bool), not just strings. qdrantFilterFrom's range filter
+// on "timestamp" can only ever match if the payload actually carries a
+// numeric value for it.
+func qdrantPayloadFromMetadata(metadata map[string]interface{}, document string) map[string]*qdrant.Value {
+	payload := map[string]*qdrant.Value{
+		"document": qdrant.NewValueString(document),
+	}
+	for k, v := range metadata {
+		if value, ok := qdrantValueFrom(v); ok {
+			payload[k] = value
+		}
+	}
+	return payload
+}
+
+func qdrantValueFrom(v interface{}) (*qdrant.Value, bool) {
+	switch t := v.(type) {
+	case string:
+		return qdrant.NewValueString(t), true
+	case bool:
+		return qdrant.NewValueBool(t), true
+	case int:
+		return qdrant.NewValueInt(int64(t)), true
+	case int64:
+		return qdrant.NewValueInt(t), true
+	case float64:
+		return qdrant.NewValueDouble(t), true
+	default:
+		return nil, false
+	}
+}
+
+// qdrantMetadataFromPayload is qdrantPayloadFromMetadata's inverse, used
+// when reading points back out (e.g. ListTracesInWindow/ListLogsInWindow)
+// so callers get plain Go values instead of *qdrant.Value wrappers.
+func qdrantMetadataFromPayload(payload map[string]*qdrant.Value) map[string]interface{} {
+	metadata := make(map[string]interface{}, len(payload))
+	for k, v := range payload {
+		switch kind := v.GetKind().(type) {
+		case *qdrant.Value_StringValue:
+			metadata[k] = kind.StringValue
+		case *qdrant.Value_BoolValue:
+			metadata[k] = kind.BoolValue
+		case *qdrant.Value_IntegerValue:
+			metadata[k] = kind.IntegerValue
+		case *qdrant.Value_DoubleValue:
+			metadata[k] = kind.DoubleValue
+		}
+	}
+	return metadata
+}
+
+func qdrantPointToRecord(p *qdrant.RetrievedPoint) VectorRecord {
+	payload := p.GetPayload()
+	document := ""
+	if v, ok := payload["document"]; ok {
+		document = v.GetStringValue()
+	}
+	delete(payload, "document")
+
+	return VectorRecord{
+		ID:       qdrantPointIDToString(p.GetId()),
+		Document: document,
+		Metadata: qdrantMetadataFromPayload(payload),
+	}
+}
+
+// qdrantPointIDToString renders a Qdrant point ID back to a string for
+// VectorRecord.ID. Since qdrantPointIDFrom always mints numeric IDs, this
+// only needs to handle that case plus UUID form defensively.
+func qdrantPointIDToString(id *qdrant.PointId) string {
+	switch v := id.GetPointIdOptions().(type) {
+	case *qdrant.PointId_Num:
+		return fmt.Sprintf("%d", v.Num)
+	case *qdrant.PointId_Uuid:
+		return v.Uuid
+	default:
+		return ""
+	}
+}
+
+func qdrantResultsToSearchResults(scored []*qdrant.ScoredPoint) []SearchResult {
+	results := make([]SearchResult, 0, len(scored))
+	for _, p := range scored {
+		document := ""
+		if v, ok := p.GetPayload()["document"]; ok {
+			document = v.GetStringValue()
+		}
+		results = append(results, SearchResult{
+			Document: document,
+			Score:    p.GetScore(),
+		})
+	}
+	return results
+}
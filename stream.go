@@ -0,0 +1,123 @@
+// stream.go
+package main
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+)
+
+// streamEvent is one Server-Sent Event. Name maps to the SSE "event:"
+// field (retrieval_started, retrieved_k_docs, llm_token, citation, ...);
+// Data is JSON-encoded and sent as "data:".
+type streamEvent struct {
+	Name string
+	Data interface{}
+}
+
+// writeSSE writes a single event in the wire format net/http's Flusher
+// expects, then flushes immediately so the browser/EventSource sees it
+// as soon as it's produced rather than buffered until the handler returns.
+func writeSSE(c *gin.Context, event streamEvent) {
+	c.SSEvent(event.Name, event.Data)
+	c.Writer.Flush()
+}
+
+// handleIncidentAnalysisStream is the streaming counterpart to
+// handleIncidentAnalysis: instead of blocking for the full 20-60s the
+// underlying LLM call can take, it emits progress as retrieval and
+// generation happen.
+func handleIncidentAnalysisStream(c *gin.Context) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+
+	query := c.Query("query")
+	clusterName := c.Query("cluster")
+
+	writeSSE(c, streamEvent{Name: "retrieval_started", Data: gin.H{"query": query}})
+
+	results, err := vectorDBClient.SearchSimilarFiltered(query, 10, SearchFilter{Cluster: clusterName})
+	if err != nil {
+		writeSSE(c, streamEvent{Name: "error", Data: gin.H{"error": err.Error()}})
+		return
+	}
+	writeSSE(c, streamEvent{Name: "retrieved_k_docs", Data: gin.H{"count": len(results)}})
+
+	tokens, _, err := streamLLMCompletion(c.Request.Context(), incidentAnalysisPrompt(query, results))
+	if err != nil {
+		writeSSE(c, streamEvent{Name: "error", Data: gin.H{"error": err.Error()}})
+		return
+	}
+
+	for token := range tokens {
+		writeSSE(c, streamEvent{Name: "llm_token", Data: gin.H{"token": token}})
+	}
+	for _, result := range results {
+		writeSSE(c, streamEvent{Name: "citation", Data: Citation{Document: result.Document, Score: float64(result.Score)}})
+	}
+}
+
+// handleTextAnalysisStream is the streaming counterpart to handleTextAnalysis.
+func handleTextAnalysisStream(c *gin.Context) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+
+	text := c.Query("text")
+
+	writeSSE(c, streamEvent{Name: "retrieval_started", Data: gin.H{}})
+
+	tokens, citations, err := streamLLMCompletion(c.Request.Context(), textAnalysisPrompt(text))
+	if err != nil {
+		writeSSE(c, streamEvent{Name: "error", Data: gin.H{"error": err.Error()}})
+		return
+	}
+
+	for token := range tokens {
+		writeSSE(c, streamEvent{Name: "llm_token", Data: gin.H{"token": token}})
+	}
+	for _, citation := range citations {
+		writeSSE(c, streamEvent{Name: "citation", Data: citation})
+	}
+}
+
+// handleLogStream pushes new log lines for a cluster/service pair as
+// they arrive, fed by the ingestion pipeline's pub/sub fan-out (see
+// logFanout in log-fanout.go) rather than polling a store.
+func handleLogStream(c *gin.Context) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+
+	cluster := c.Query("cluster")
+	service := c.Query("service")
+
+	sub := logFanout.Subscribe(cluster, service)
+	defer logFanout.Unsubscribe(sub)
+
+	ctx := c.Request.Context()
+	for {
+		select {
+		case line, ok := <-sub.Lines:
+			if !ok {
+				return
+			}
+			writeSSE(c, streamEvent{Name: "log_line", Data: line})
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func incidentAnalysisPrompt(query string, results []SearchResult) string {
+	prompt := fmt.Sprintf("Incident query: %s\n\nRelevant traces:\n", query)
+	for _, r := range results {
+		prompt += fmt.Sprintf("- %s\n", r.Document)
+	}
+	return prompt
+}
+
+func textAnalysisPrompt(text string) string {
+	return fmt.Sprintf("Analyze the following log/trace text:\n%s", text)
+}
@@ -0,0 +1,64 @@
+// telemetry.go
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+)
+
+// tracer — трейсер самого анализатора, используемый из обработчиков и
+// VectorDBClient. Берём его через otel.Tracer(...) сразу при старте, а
+// не ждём успешного initTelemetry: до WithTracerProvider он резолвится в
+// no-op трейсер global provider'а, так что SaveTraces/SearchSimilarIssues
+// не падают на tracer.Start, даже если самоинструментация отключена или
+// не смогла подключиться к коллектору. initTelemetry лишь подменяет
+// глобальный provider на реальный экспортирующий, если ему это удаётся.
+var tracer = otel.Tracer("ai-logs-traces-analyzer")
+
+// initTelemetry настраивает OTLP-экспортёр трейсов для самоинструментации.
+// Если OTEL_EXPORTER_OTLP_ENDPOINT не задан, используется локальный
+// коллектор на :4317 — тот же порт, на который мы сами принимаем OTLP,
+// что удобно для догфудинга в одном инстансе.
+func initTelemetry(ctx context.Context) (shutdown func(context.Context) error, err error) {
+	endpoint := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+	if endpoint == "" {
+		endpoint = "localhost:4317"
+	}
+
+	exporter, err := otlptracegrpc.New(ctx,
+		otlptracegrpc.WithEndpoint(endpoint),
+		otlptracegrpc.WithInsecure(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithAttributes(
+			semconv.ServiceName("ai-logs-traces-analyzer"),
+		),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	// otel.Tracer(...) (what `tracer` above already holds) proxies to
+	// whatever provider is currently registered, so setting it here is
+	// enough — no need to re-fetch a tracer handle.
+	otel.SetTracerProvider(provider)
+
+	log.Printf("Self-instrumentation enabled, exporting traces to %s", endpoint)
+
+	return provider.Shutdown, nil
+}
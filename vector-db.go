@@ -3,67 +3,153 @@ package main
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"log"
-
-	"github.com/amikos-tech/chroma-go"
+	"time"
 )
 
+// VectorDBClient is the analyzer-facing API used by the rest of the
+// codebase (handlers, correlation engine, etc). It no longer talks to
+// ChromaDB directly — it delegates to whichever VectorStore is
+// configured, so swapping backends doesn't touch call sites.
 type VectorDBClient struct {
-	client *chroma.Client
+	store    VectorStore
+	embedder Embedder
 }
 
 func NewVectorDBClient(host string) (*VectorDBClient, error) {
-	client, err := chroma.NewClient(host)
+	store, err := newVectorStore(host)
+	if err != nil {
+		return nil, err
+	}
+	return &VectorDBClient{store: store, embedder: newConfiguredEmbedder()}, nil
+}
+
+// NewVectorDBClientWithEmbedder lets callers (mainly tests) supply their
+// own Embedder instead of the configured ONNX/OpenAI backend.
+func NewVectorDBClientWithEmbedder(host string, embedder Embedder) (*VectorDBClient, error) {
+	store, err := newVectorStore(host)
 	if err != nil {
 		return nil, err
 	}
-	return &VectorDBClient{client: client}, nil
+	return &VectorDBClient{store: store, embedder: embedder}, nil
 }
 
-func (v *VectorDBClient) SaveTraces(traceData map[string]interface{}) error {
-	collection, err := v.client.GetOrCreateCollection("traces", nil)
+func (v *VectorDBClient) SaveTraces(clusterName string, traceData map[string]interface{}) error {
+	ctx, span := tracer.Start(context.Background(), "VectorDBClient.SaveTraces")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		vectorDBCallLatency.WithLabelValues("save_traces").Observe(time.Since(start).Seconds())
+	}()
+
+	documents, metadatas, ids := processTracesForVectorDB(traceData)
+	batch, err := v.embedBatch(ctx, documents, metadatas, ids)
 	if err != nil {
 		return err
 	}
-	
-	// Преобразуем трейсы в векторное представление
-	documents, metadatas, ids, embeddings := processTracesForVectorDB(traceData)
-	
-	_, err = collection.Add(
-		context.Background(),
-		chroma.NewAddEmbeddings(embeddings).
-			WithDocuments(documents).
-			WithMetadatas(metadatas).
-			WithIDs(ids),
-	)
-	
-	return err
+
+	return v.store.SaveTraces(ctx, clusterName, batch)
 }
 
-func (v *VectorDBClient) SearchSimilarIssues(query string, limit int) ([]SearchResult, error) {
-	collection, err := v.client.GetCollection("traces")
+func (v *VectorDBClient) SaveLogs(clusterName string, logData map[string]interface{}) error {
+	ctx, span := tracer.Start(context.Background(), "VectorDBClient.SaveLogs")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		vectorDBCallLatency.WithLabelValues("save_logs").Observe(time.Since(start).Seconds())
+	}()
+
+	documents, metadatas, ids := processLogsForVectorDB(logData)
+	batch, err := v.embedBatch(ctx, documents, metadatas, ids)
 	if err != nil {
-		return nil, err
+		return err
+	}
+
+	return v.store.SaveLogs(ctx, clusterName, batch)
+}
+
+// embedBatch runs every document in a save through the configured
+// Embedder in a single call, rather than each backend's SaveTraces/SaveLogs
+// embedding per-item — that's what lets the batching/dedup Embedder (see
+// embedder.go) actually coalesce ingestion-time embedding calls instead of
+// only ever seeing one document at a time from the query path.
+func (v *VectorDBClient) embedBatch(ctx context.Context, documents []string, metadatas []map[string]interface{}, ids []string) (VectorBatch, error) {
+	if len(documents) == 0 {
+		return VectorBatch{}, nil
 	}
-	
-	// Получаем эмбеддинг для запроса
-	queryEmbedding, err := getEmbedding(query)
+
+	embeddingStart := time.Now()
+	embeddings, err := v.embedder.Embed(ctx, documents)
+	embeddingLatency.WithLabelValues("default").Observe(time.Since(embeddingStart).Seconds())
 	if err != nil {
-		return nil, err
+		return VectorBatch{}, err
 	}
-	
-	results, err := collection.Query(
-		context.Background(),
-		chroma.NewQueryEmbeddings([][]float32{queryEmbedding}).
-			WithNResults(limit).
-			WithInclude([]string{"metadatas", "documents", "distances"}),
-	)
-	
+
+	return VectorBatch{Documents: documents, Metadatas: metadatas, IDs: ids, Embeddings: embeddings}, nil
+}
+
+// SearchSimilarIssues is SearchSimilarFiltered with every other axis left
+// unset. clusterName is still required: every backend namespaces storage
+// by cluster (collectionForCluster, weaviateClassName, the pgvector
+// "cluster" column), so leaving it empty wouldn't search "everything" —
+// it would search whatever each backend maps an empty cluster name to,
+// which is never where a real tenant's data was ingested.
+func (v *VectorDBClient) SearchSimilarIssues(clusterName, query string, limit int) ([]SearchResult, error) {
+	return v.SearchSimilarFiltered(query, limit, SearchFilter{Cluster: clusterName})
+}
+
+func (v *VectorDBClient) SearchSimilarFiltered(query string, limit int, filter SearchFilter) ([]SearchResult, error) {
+	ctx, span := tracer.Start(context.Background(), "VectorDBClient.SearchSimilarIssues")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		vectorDBCallLatency.WithLabelValues("search_similar_issues").Observe(time.Since(start).Seconds())
+	}()
+
+	embeddingStart := time.Now()
+	queryEmbeddings, err := v.embedder.Embed(ctx, []string{query})
+	embeddingLatency.WithLabelValues("default").Observe(time.Since(embeddingStart).Seconds())
 	if err != nil {
 		return nil, err
 	}
-	
-	return convertToSearchResults(results), nil
-}
\ No newline at end of file
+
+	return v.store.SearchSimilar(ctx, queryEmbeddings[0], limit, filter)
+}
+
+// ListTracesInWindow and ListLogsInWindow back the incident correlation
+// engine's windowed scans (see correlation-datasource.go) — unlike
+// SearchSimilarFiltered, there's no query to embed, so these go straight
+// to the store.
+func (v *VectorDBClient) ListTracesInWindow(clusterName string, from, to time.Time) ([]VectorRecord, error) {
+	ctx, span := tracer.Start(context.Background(), "VectorDBClient.ListTracesInWindow")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		vectorDBCallLatency.WithLabelValues("list_traces_in_window").Observe(time.Since(start).Seconds())
+	}()
+
+	return v.store.ListTracesInWindow(ctx, clusterName, from, to)
+}
+
+func (v *VectorDBClient) ListLogsInWindow(clusterName string, from, to time.Time) ([]VectorRecord, error) {
+	ctx, span := tracer.Start(context.Background(), "VectorDBClient.ListLogsInWindow")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		vectorDBCallLatency.WithLabelValues("list_logs_in_window").Observe(time.Since(start).Seconds())
+	}()
+
+	return v.store.ListLogsInWindow(ctx, clusterName, from, to)
+}
+
+func (v *VectorDBClient) DeleteByCluster(clusterName string) error {
+	return v.store.DeleteByCluster(context.Background(), clusterName)
+}
+
+func (v *VectorDBClient) Compact() error {
+	return v.store.Compact(context.Background())
+}
@@ -0,0 +1,111 @@
+// vector-store.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+)
+
+// embeddingDimensions must match the output size of whatever Embedder is
+// configured (see embedder.go); all-MiniLM-L6-v2 and the OpenAI
+// compatibility shims we support both emit 384/1536-dim vectors, so this
+// is overridable via EMBEDDING_DIMENSIONS for backends that need the
+// size up front (e.g. Qdrant collection creation).
+var embeddingDimensions uint64 = 384
+
+func init() {
+	if v := os.Getenv("EMBEDDING_DIMENSIONS"); v != "" {
+		if n, err := parsePositiveUint(v); err == nil {
+			embeddingDimensions = n
+		}
+	}
+}
+
+func parsePositiveUint(s string) (uint64, error) {
+	var n uint64
+	_, err := fmt.Sscanf(s, "%d", &n)
+	return n, err
+}
+
+// SearchFilter narrows SearchSimilar to a time range and/or a few common
+// span/log attributes. Every backend maps this onto its own metadata
+// filter syntax; a zero-value field means "no constraint on that axis".
+type SearchFilter struct {
+	Cluster     string
+	ServiceName string
+	SpanKind    string
+	From        time.Time
+	To          time.Time
+}
+
+// VectorBatch is one set of documents ready to upsert into a VectorStore:
+// embeddings are already computed by the time a batch reaches the store,
+// so every backend just writes them rather than each calling an Embedder
+// on its own per-item hot path (see VectorDBClient.SaveTraces/SaveLogs).
+type VectorBatch struct {
+	Documents  []string
+	Metadatas  []map[string]interface{}
+	IDs        []string
+	Embeddings [][]float32
+}
+
+// VectorRecord is one row out of a ListTracesInWindow/ListLogsInWindow
+// scan: the same document+metadata a backend stored on SaveTraces/SaveLogs,
+// returned as-is rather than ranked by embedding distance like
+// SearchSimilar does. The correlation engine (correlation-datasource.go)
+// decodes Metadata back into a Span or LogLine.
+type VectorRecord struct {
+	ID       string
+	Document string
+	Metadata map[string]interface{}
+}
+
+// VectorStore is the seam between the analyzer and whatever vector
+// database a deployment runs. Every method is namespaced by cluster so
+// that one tenant's SearchSimilar can never surface another tenant's
+// traces, even if both share the same physical backend.
+type VectorStore interface {
+	SaveTraces(ctx context.Context, cluster string, batch VectorBatch) error
+	SaveLogs(ctx context.Context, cluster string, batch VectorBatch) error
+	SearchSimilar(ctx context.Context, queryEmbedding []float32, limit int, filter SearchFilter) ([]SearchResult, error)
+	// ListTracesInWindow and ListLogsInWindow are plain metadata-filtered
+	// scans (no query vector involved) over everything saved for cluster
+	// with a "timestamp" in [from, to]. They back the incident
+	// correlation engine, which needs every span/log in a window rather
+	// than the top-K nearest to a query.
+	ListTracesInWindow(ctx context.Context, cluster string, from, to time.Time) ([]VectorRecord, error)
+	ListLogsInWindow(ctx context.Context, cluster string, from, to time.Time) ([]VectorRecord, error)
+	DeleteByCluster(ctx context.Context, cluster string) error
+	Compact(ctx context.Context) error
+}
+
+// newVectorStore selects a backend from VECTOR_STORE_BACKEND (default
+// "chroma", the only backend this project originally shipped with) so
+// on-prem deployments aren't forced onto chroma-go.
+func newVectorStore(host string) (VectorStore, error) {
+	switch backend := os.Getenv("VECTOR_STORE_BACKEND"); backend {
+	case "", "chroma":
+		return newChromaStore(host)
+	case "qdrant":
+		return newQdrantStore(host)
+	case "weaviate":
+		return newWeaviateStore(host)
+	case "pgvector":
+		return newPgVectorStore(host)
+	default:
+		return nil, fmt.Errorf("unknown VECTOR_STORE_BACKEND %q", backend)
+	}
+}
+
+// collectionForCluster returns the per-tenant collection name used by the
+// Chroma and Qdrant backends (Weaviate uses weaviateClassName, pgvector a
+// "cluster" column), so a cluster can only ever read its own traces back
+// out of SearchSimilar.
+func collectionForCluster(cluster string) string {
+	if cluster == "" {
+		cluster = "default"
+	}
+	return "traces_" + cluster
+}
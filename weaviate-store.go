@@ -0,0 +1,314 @@
+// weaviate-store.go
+package main
+
+import (
+	"context"
+	"crypto/sha1"
+	"fmt"
+	"time"
+
+	"github.com/weaviate/weaviate-go-client/v4/weaviate"
+	"github.com/weaviate/weaviate-go-client/v4/weaviate/filters"
+	"github.com/weaviate/weaviate-go-client/v4/weaviate/graphql"
+	"github.com/weaviate/weaviate/entities/models"
+)
+
+// vectorRecordFields are the metadata properties the correlation engine
+// needs back out of a window scan (see correlation-datasource.go). Unlike
+// SearchSimilar, which only ever needs "document", GraphQL requires
+// naming every property up front, so this list has to be kept in sync
+// with whatever processTracesForVectorDB/processLogsForVectorDB put into
+// each span/log's metadata.
+var vectorRecordFields = []graphql.Field{
+	{Name: "document"},
+	{Name: "service_name"},
+	{Name: "span_kind"},
+	{Name: "timestamp"},
+	{Name: "trace_id"},
+	{Name: "span_id"},
+	{Name: "parent_span_id"},
+	{Name: "pod_name"},
+	{Name: "is_error"},
+	{Name: "end_time"},
+	{Name: "_additional", Fields: []graphql.Field{{Name: "id"}}},
+}
+
+// weaviateStore uses one Weaviate "class" per cluster (Weaviate classes
+// are PascalCase and can't contain "-", hence weaviateClassName below)
+// so SearchSimilar can never cross tenant boundaries.
+type weaviateStore struct {
+	client *weaviate.Client
+}
+
+func newWeaviateStore(host string) (*weaviateStore, error) {
+	cfg := weaviate.Config{Host: host, Scheme: "http"}
+	return &weaviateStore{client: weaviate.New(cfg)}, nil
+}
+
+func weaviateClassName(cluster string) string {
+	return "Traces_" + sanitizeForWeaviateClass(cluster)
+}
+
+func (s *weaviateStore) ensureClass(ctx context.Context, class string) error {
+	exists, err := s.client.Schema().ClassExistenceChecker().WithClassName(class).Do(ctx)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+
+	return s.client.Schema().ClassCreator().WithClass(&models.Class{
+		Class:      class,
+		Vectorizer: "none", // we supply embeddings ourselves, see embedder.go
+	}).Do(ctx)
+}
+
+func (s *weaviateStore) SaveTraces(ctx context.Context, cluster string, batch VectorBatch) error {
+	class := weaviateClassName(cluster)
+	if err := s.ensureClass(ctx, class); err != nil {
+		return err
+	}
+	return s.upsertBatch(ctx, class, batch)
+}
+
+func (s *weaviateStore) SaveLogs(ctx context.Context, cluster string, batch VectorBatch) error {
+	class := weaviateClassName(cluster) + "_Logs"
+	if err := s.ensureClass(ctx, class); err != nil {
+		return err
+	}
+	return s.upsertBatch(ctx, class, batch)
+}
+
+func (s *weaviateStore) upsertBatch(ctx context.Context, class string, batch VectorBatch) error {
+	objects := make([]*models.Object, 0, len(batch.IDs))
+	for i := range batch.IDs {
+		props := map[string]interface{}{"document": batch.Documents[i]}
+		for k, v := range batch.Metadatas[i] {
+			props[k] = v
+		}
+		objects = append(objects, &models.Object{
+			Class:      class,
+			ID:         weaviateUUIDFrom(batch.IDs[i]),
+			Properties: props,
+			Vector:     batch.Embeddings[i],
+		})
+	}
+
+	_, err := s.client.Batch().ObjectsBatcher().WithObjects(objects...).Do(ctx)
+	return err
+}
+
+func (s *weaviateStore) SearchSimilar(ctx context.Context, queryEmbedding []float32, limit int, filter SearchFilter) ([]SearchResult, error) {
+	class := weaviateClassName(filter.Cluster)
+
+	nearVector := s.client.GraphQL().NearVectorArgBuilder().WithVector(queryEmbedding)
+
+	query := s.client.GraphQL().Get().
+		WithClassName(class).
+		WithFields(
+			graphql.Field{Name: "document"},
+			graphql.Field{Name: "_additional", Fields: []graphql.Field{{Name: "certainty"}}},
+		).
+		WithNearVector(nearVector).
+		WithLimit(limit)
+
+	if where := weaviateWhereFilter(filter); where != nil {
+		query = query.WithWhere(where)
+	}
+
+	resp, err := query.Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return weaviateResponseToSearchResults(resp, class), nil
+}
+
+func (s *weaviateStore) ListTracesInWindow(ctx context.Context, cluster string, from, to time.Time) ([]VectorRecord, error) {
+	return s.listInWindow(ctx, weaviateClassName(cluster), from, to)
+}
+
+func (s *weaviateStore) ListLogsInWindow(ctx context.Context, cluster string, from, to time.Time) ([]VectorRecord, error) {
+	return s.listInWindow(ctx, weaviateClassName(cluster)+"_Logs", from, to)
+}
+
+// weaviateListPageSize bounds each Get page in listInWindow; without an
+// explicit limit/offset, Weaviate applies its own server-side default cap
+// and a window with more objects than that would come back truncated.
+const weaviateListPageSize = 100
+
+// listInWindow is a plain Get, deliberately without WithNearVector: a
+// window scan has no query vector to rank against, only a "timestamp"
+// range to filter on. It pages with Limit/Offset until a page comes back
+// short, since Weaviate doesn't surface a result count up front.
+func (s *weaviateStore) listInWindow(ctx context.Context, class string, from, to time.Time) ([]VectorRecord, error) {
+	where := weaviateWhereFilter(SearchFilter{From: from, To: to})
+
+	var records []VectorRecord
+	for offset := 0; ; offset += weaviateListPageSize {
+		resp, err := s.client.GraphQL().Get().
+			WithClassName(class).
+			WithFields(vectorRecordFields...).
+			WithWhere(where).
+			WithLimit(weaviateListPageSize).
+			WithOffset(offset).
+			Do(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		page := weaviateResponseToRecords(resp, class)
+		records = append(records, page...)
+		if len(page) < weaviateListPageSize {
+			return records, nil
+		}
+	}
+}
+
+func (s *weaviateStore) DeleteByCluster(ctx context.Context, cluster string) error {
+	return s.client.Schema().ClassDeleter().WithClassName(weaviateClassName(cluster)).Do(ctx)
+}
+
+func (s *weaviateStore) Compact(ctx context.Context) error {
+	// Weaviate handles LSM compaction internally; no client-facing hook.
+	return nil
+}
+
+func weaviateWhereFilter(filter SearchFilter) *filters.WhereBuilder {
+	var operands []*filters.WhereBuilder
+	if filter.ServiceName != "" {
+		operands = append(operands, filters.Where().
+			WithPath([]string{"service_name"}).
+			WithOperator(filters.Equal).
+			WithValueString(filter.ServiceName))
+	}
+	if filter.SpanKind != "" {
+		operands = append(operands, filters.Where().
+			WithPath([]string{"span_kind"}).
+			WithOperator(filters.Equal).
+			WithValueString(filter.SpanKind))
+	}
+	if !filter.From.IsZero() {
+		operands = append(operands, filters.Where().
+			WithPath([]string{"timestamp"}).
+			WithOperator(filters.GreaterThanEqual).
+			WithValueNumber(float64(filter.From.UnixNano())))
+	}
+	if !filter.To.IsZero() {
+		operands = append(operands, filters.Where().
+			WithPath([]string{"timestamp"}).
+			WithOperator(filters.LessThanEqual).
+			WithValueNumber(float64(filter.To.UnixNano())))
+	}
+	if len(operands) == 0 {
+		return nil
+	}
+	if len(operands) == 1 {
+		return operands[0]
+	}
+	return filters.Where().WithOperator(filters.And).WithOperands(operands)
+}
+
+func sanitizeForWeaviateClass(cluster string) string {
+	if cluster == "" {
+		return "Default"
+	}
+	out := make([]rune, 0, len(cluster))
+	for _, r := range cluster {
+		if r == '-' || r == '.' {
+			out = append(out, '_')
+			continue
+		}
+		out = append(out, r)
+	}
+	return string(out)
+}
+
+func weaviateUUIDFrom(id string) string {
+	// Weaviate object IDs must be UUIDs; our trace/span IDs aren't, and
+	// can be shorter than the 20 bytes a naive slice-based split needs,
+	// so we hash the ID to a fixed-length digest first and then lay out
+	// that digest in UUID form. This keeps the derivation deterministic
+	// (same span ID -> same UUID, so re-ingestion stays idempotent)
+	// without risking an out-of-range slice on short IDs.
+	sum := sha1.Sum([]byte(id))
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+// weaviateResponseToSearchResults unpacks the nested
+// {"data":{"Get":{<class>:[{...}]}}} shape a GraphQL Get query returns
+// into the flat []SearchResult the rest of the codebase expects.
+func weaviateResponseToSearchResults(resp *graphql.GraphQLResponse, class string) []SearchResult {
+	get, ok := resp.Data["Get"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	rows, ok := get[class].([]interface{})
+	if !ok {
+		return nil
+	}
+
+	results := make([]SearchResult, 0, len(rows))
+	for _, row := range rows {
+		obj, ok := row.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		document, _ := obj["document"].(string)
+
+		var score float64
+		if additional, ok := obj["_additional"].(map[string]interface{}); ok {
+			if certainty, ok := additional["certainty"].(float64); ok {
+				score = certainty
+			}
+		}
+
+		results = append(results, SearchResult{Document: document, Score: score})
+	}
+	return results
+}
+
+// weaviateResponseToRecords mirrors weaviateResponseToSearchResults but
+// keeps every requested metadata field instead of just "document", and
+// reads the object's own UUID (via _additional.id) rather than a
+// similarity score.
+func weaviateResponseToRecords(resp *graphql.GraphQLResponse, class string) []VectorRecord {
+	get, ok := resp.Data["Get"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	rows, ok := get[class].([]interface{})
+	if !ok {
+		return nil
+	}
+
+	records := make([]VectorRecord, 0, len(rows))
+	for _, row := range rows {
+		obj, ok := row.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		document, _ := obj["document"].(string)
+
+		var id string
+		if additional, ok := obj["_additional"].(map[string]interface{}); ok {
+			id, _ = additional["id"].(string)
+		}
+
+		metadata := make(map[string]interface{}, len(obj))
+		for k, v := range obj {
+			if k == "document" || k == "_additional" {
+				continue
+			}
+			metadata[k] = v
+		}
+
+		records = append(records, VectorRecord{ID: id, Document: document, Metadata: metadata})
+	}
+	return records
+}